@@ -21,7 +21,7 @@ func Register(ginRouter *ginAPI.RouterGroup) {
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
 
-		model := gin.MustGet("model").(*mnemosyne.Mnemosyne)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
 		err := model.PingContext(ctx)
 		if err != nil {
@@ -7,6 +7,8 @@
 package app
 
 import (
+	"os"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
@@ -23,7 +25,7 @@ type App struct {
 	gin *gin.Engine
 	zap *zap.Logger
 
-	Mnemosyne *mnemosyne.Mnemosyne
+	Mnemosyne mnemosyne.Mnemosyne
 }
 
 // Configure the app and run it
@@ -45,7 +47,23 @@ func ConfigureAppAndRun() {
 	log := zapr.NewLogger(app.zap)
 
 	// Configure persistance layer
-	app.Mnemosyne = mnemosyne.New(log)
+	databaseDriver := os.Getenv("TEXEL_DATABASE_DRIVER")
+	if databaseDriver == "" {
+		databaseDriver = mnemosyne.DefaultDriver
+	}
+
+	databasePath := os.Getenv("TEXEL_DATABASE_PATH")
+	if databasePath == "" {
+		databasePath = mnemosyne.DefaultDatabasePath
+	}
+
+	// SQLite takes a path, Postgres a connection string
+	dataSource := databasePath
+	if databaseDriver == mnemosyne.DriverPostgres {
+		dataSource = os.Getenv("TEXEL_DATABASE_URL")
+	}
+
+	app.Mnemosyne = mnemosyne.New(log, databaseDriver, dataSource)
 	defer app.Mnemosyne.Drop()
 
 	// Configure all required middlewares
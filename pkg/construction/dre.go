@@ -7,11 +7,15 @@
 package construction
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"time"
 
 	"github.com/paulmach/orb"
-	"github.com/paulmach/orb/clip"
 	"github.com/paulmach/orb/geojson"
 	"github.com/paulmach/orb/planar"
 )
@@ -24,188 +28,901 @@ const (
 	DesignRuleViolationOverlapped DesignRuleViolation = iota
 	DesignRuleViolationNotClosed
 	DesignRuleViolationNotPolygon
+	DesignRuleViolationSelfIntersection
+	DesignRuleViolationSliver
+	DesignRuleViolationPropertySchema
+	DesignRuleViolationWindingOrder
+	DesignRuleViolationCoordinateRange
 
 	// Splits
 	DesignRuleViolationOutOfBound
+	DesignRuleViolationNotCovered
 )
 
-type DesignRuleFuncOne func(featureCollection *geojson.FeatureCollection) (ok bool)
-type DesignRuleFuncMany func(featureCollectionA, featureCollectionB *geojson.FeatureCollection) (ok bool)
+// Design rules report one violation per offence; the engine fills in the rule and feature ids.
+// The parameters hold the defaults of the rule overridden by the settings of the engine.
+// DesignRuleFuncOne and DesignRuleFuncMany are the checks of the collection and split rules. ctx is done once the
+// validation is abandoned, e.g. on a timeout: nobody waits for the rule anymore, so long-running rules should give up.
+type DesignRuleFuncOne func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation)
+type DesignRuleFuncMany func(ctx context.Context, params Parameters, featureCollectionL, featureCollectionP *geojson.FeatureCollection) (violations []Violation)
 
-var (
-	rulesCollection map[DesignRuleViolation]DesignRuleFuncOne  = map[DesignRuleViolation]DesignRuleFuncOne{}
-	rulesSplits     map[DesignRuleViolation]DesignRuleFuncMany = map[DesignRuleViolation]DesignRuleFuncMany{}
-)
+type DesignRuleEngineOption func(dre *DesignRuleEngine)
+
+// WithOverlapAreaTolerance accepts polygons sharing less than tolerance (square metres)
+func WithOverlapAreaTolerance(tolerance float64) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.mustConfigure(DesignRuleViolationOverlapped.String(), RuleSettings{Parameters: Parameters{ParameterAreaTolerance: tolerance}})
+	}
+}
+
+// WithOutOfBoundAreaTolerance accepts height plateaux sticking out of the building limits by less than tolerance (square metres)
+func WithOutOfBoundAreaTolerance(tolerance float64) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.mustConfigure(DesignRuleViolationOutOfBound.String(), RuleSettings{Parameters: Parameters{ParameterAreaTolerance: tolerance}})
+	}
+}
+
+// WithRules registers additional rules, enabled with their default parameters
+func WithRules(rules ...Rule) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		for _, rule := range rules {
+			dre.Register(rule)
+		}
+	}
+}
+
+// WithRuleSet applies the settings of a project. Unknown rules and parameters are skipped,
+// so that a rule retired from the engine doesn't lock a project out; see Configure.
+func WithRuleSet(ruleSet RuleSet) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		_ = dre.Configure(ruleSet)
+	}
+}
+
+// WithConcurrency evaluates up to workers rules at once; GOMAXPROCS by default
+func WithConcurrency(workers int) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.workers = max(1, workers)
+	}
+}
+
+// WithProjection measures the areas compared against the area tolerances in the plane of projection, e.g. the CRS of a project.
+// By default every validation picks the UTM zone of the centre of its collections.
+func WithProjection(projection Projection) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.projection = &projection
+	}
+}
+
+// WithTimeout bounds every validation by timeout on top of the caller's deadline
+func WithTimeout(timeout time.Duration) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.timeout = timeout
+	}
+}
+
+// ErrRulePanicked is returned when a rule panics, the rule is at fault rather than the features
+var ErrRulePanicked = errors.New("design rule panicked")
+
+// Built-in rules every engine starts with
+var builtinRules = map[DesignRuleViolation]Rule{}
 
 func init() {
-	designRuleRegisterCollection(DesignRuleViolationOverlapped,
-		func(featureCollection *geojson.FeatureCollection) (ok bool) {
-			polygons := []orb.Polygon{}
+	designRuleRegisterCollection(DesignRuleViolationOverlapped, SeverityError, Parameters{ParameterAreaTolerance: 0},
+		func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+			polygons, indices, tree := polygonFeatures(featureCollection)
+			projection := ProjectionFromContext(ctx, featureCollection)
 
-			for _, f := range featureCollection.Features {
-				p, ok := f.Geometry.(orb.Polygon)
+			// Overlaps are summed up per pair of features, whatever the number of their components
+			overlaps := map[[2]int]orb.MultiPolygon{}
+			pairs := [][2]int{}
 
-				// Skip all elements other than Polygon because those are being taken care off by NotPolygon rule
-				if !ok {
-					continue
+			for i := 0; i < len(polygons); i++ {
+				// Nobody waits for the outcome anymore
+				if ctx.Err() != nil {
+					return nil
 				}
 
-				polygons = append(polygons, p)
-			}
+				// Only polygons with intersecting bounds can overlap
+				for _, j := range tree.search(polygons[i].Bound()) {
+					// Components of the same feature are taken care of by the SelfIntersection rule
+					if j <= i || indices[j] == indices[i] {
+						continue
+					}
 
-			for i := 0; i < len(polygons); i++ {
-				for j := i + 1; j < len(polygons); j++ {
-					if overlapped := polygonsOverlapped(polygons[i], polygons[j]); overlapped {
-						return false
+					overlap := polygonIntersection(orb.MultiPolygon{polygons[i]}, orb.MultiPolygon{polygons[j]})
+					if len(overlap) == 0 {
+						continue
 					}
+
+					pair := [2]int{min(indices[i], indices[j]), max(indices[i], indices[j])}
+					if _, ok := overlaps[pair]; !ok {
+						pairs = append(pairs, pair)
+					}
+					overlaps[pair] = append(overlaps[pair], overlap...)
 				}
 			}
 
-			return true
+			for _, pair := range pairs {
+				if overlap := overlaps[pair]; projection.Area(overlap) > params[ParameterAreaTolerance] {
+					violations = append(violations, Violation{
+						Features: []FeatureRef{{Index: pair[0]}, {Index: pair[1]}},
+						Geometry: overlap,
+						Message:  fmt.Sprintf("features %d and %d overlap", pair[0], pair[1]),
+					})
+				}
+			}
+
+			return
 		})
 
-	designRuleRegisterCollection(DesignRuleViolationNotClosed, func(featureCollection *geojson.FeatureCollection) (ok bool) {
-		for _, f := range featureCollection.Features {
-			p, ok := f.Geometry.(orb.Polygon)
+	designRuleRegisterCollection(DesignRuleViolationNotClosed, SeverityError, nil, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		for i, f := range featureCollection.Features {
+			polygons, ok := featurePolygons(f.Geometry)
 
-			// Skip all elements other than Polygon because those are being taken care off by NotPolygon rule
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
 			if !ok {
 				continue
 			}
 
-			if closed := polygonClosed(p); !closed {
-				return false
+			// Report both loose ends of every open ring
+			looseEnds := orb.MultiPoint{}
+			for _, p := range polygons {
+				for _, ring := range p {
+					if len(ring) > 0 && !ring.Closed() {
+						looseEnds = append(looseEnds, ring[0], ring[len(ring)-1])
+					}
+				}
+			}
+
+			if len(looseEnds) != 0 {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: looseEnds,
+					Message:  fmt.Sprintf("feature %d has a ring which isn't closed", i),
+				})
 			}
 		}
 
-		return true
+		return
 	})
 
-	designRuleRegisterCollection(DesignRuleViolationNotPolygon, func(featureCollection *geojson.FeatureCollection) (ok bool) {
-		for _, f := range featureCollection.Features {
-			if f.Geometry.GeoJSONType() != "Polygon" {
-				return false
+	// Slivers are accepted, yet they are likely digitising mistakes
+	designRuleRegisterCollection(DesignRuleViolationSliver, SeverityWarning, Parameters{ParameterMinThinness: 0.05}, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		for i, f := range featureCollection.Features {
+			polygons, ok := featurePolygons(f.Geometry)
+
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+			if !ok {
+				continue
+			}
+
+			// Every component is judged on its own
+			slivers := orb.MultiPolygon{}
+			thinnest := math.Inf(1)
+			for _, p := range polygons {
+				if thinness := polygonThinness(p); thinness < params[ParameterMinThinness] {
+					slivers = append(slivers, p)
+					thinnest = math.Min(thinnest, thinness)
+				}
+			}
+
+			if len(slivers) != 0 {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: singlePolygon(slivers),
+					Message:  fmt.Sprintf("feature %d is a sliver, its thinness is %.3g", i, thinnest),
+				})
 			}
 		}
 
-		return true
+		return
 	})
 
-	designRuleRegisterSplits(DesignRuleViolationOutOfBound, func(featureCollectionL, featureCollectionP *geojson.FeatureCollection) (ook bool) {
-		for _, f := range featureCollectionP.Features {
-			outOfBound := true
-			pPlateau, ok := f.Geometry.(orb.Polygon)
+	designRuleRegisterCollection(DesignRuleViolationSelfIntersection, SeverityError, nil, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		for i, f := range featureCollection.Features {
+			polygons, ok := featurePolygons(f.Geometry)
 
-			// Skip all elements other than Polygon because those are being taken care off by NotPolygon rule
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
 			if !ok {
 				continue
 			}
 
-			for _, f := range featureCollectionL.Features {
-				pLimit, ok := f.Geometry.(orb.Polygon)
+			if coordinates := multiPolygonSelfIntersections(polygons); len(coordinates) != 0 {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: orb.MultiPoint(coordinates),
+					Message:  fmt.Sprintf("feature %d intersects itself at %v", i, coordinates),
+				})
+			}
+		}
 
-				// Skip all elements other than Polygon because those are being taken care off by NotPolygon rule
-				if !ok {
-					continue
-				}
+		return
+	})
 
-				if planar.PolygonContains(pLimit, pPlateau.Bound().Min) && planar.PolygonContains(pLimit, pPlateau.Bound().Max) {
-					// Found the right pLimit
-					outOfBound = false
+	designRuleRegisterCollection(DesignRuleViolationNotPolygon, SeverityError, nil, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		for i, f := range featureCollection.Features {
+			if f.Geometry == nil {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Message:  fmt.Sprintf("feature %d has no geometry", i),
+				})
+				continue
+			}
+
+			if _, ok := featurePolygons(f.Geometry); !ok {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: f.Geometry,
+					Message:  fmt.Sprintf("feature %d is a %s, not a Polygon or MultiPolygon", i, f.Geometry.GeoJSONType()),
+				})
+			}
+		}
+
+		return
+	})
+
+	designRuleRegisterSplits(DesignRuleViolationOutOfBound, SeverityError, Parameters{ParameterAreaTolerance: 0}, func(ctx context.Context, params Parameters, featureCollectionL, featureCollectionP *geojson.FeatureCollection) (violations []Violation) {
+		limits, _, tree := polygonFeatures(featureCollectionL)
+		projection := ProjectionFromContext(ctx, featureCollectionL, featureCollectionP)
+
+		for i, f := range featureCollectionP.Features {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			pPlateau, ok := featurePolygons(f.Geometry)
+
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+			if !ok {
+				continue
+			}
+
+			// A plateau may legitimately span several adjacent limits, so cut away every nearby limit in turn.
+			// Whatever is left of the plateau is out of bound.
+			outOfBound := pPlateau.Clone()
+			for _, j := range tree.search(pPlateau.Bound()) {
+				if len(outOfBound) == 0 {
 					break
 				}
 
+				outOfBound = polygonDifference(outOfBound, orb.MultiPolygon{limits[j]})
 			}
 
-			if outOfBound {
-				return false
+			if area := projection.Area(outOfBound); area > params[ParameterAreaTolerance] {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Layer: LayerHeightPlateaux, Index: i}},
+					Geometry: outOfBound,
+					Message:  fmt.Sprintf("height plateau %d lies outside of the building limits by %.2f m²", i, area),
+				})
 			}
+		}
 
+		return
+	})
+
+	// Building limits left without an elevation make for an incomplete split
+	designRuleRegisterSplits(DesignRuleViolationNotCovered, SeverityError, Parameters{ParameterAreaTolerance: 0}, func(ctx context.Context, params Parameters, featureCollectionL, featureCollectionP *geojson.FeatureCollection) (violations []Violation) {
+		plateaux, _, tree := polygonFeatures(featureCollectionP)
+		projection := ProjectionFromContext(ctx, featureCollectionL, featureCollectionP)
+
+		for i, f := range featureCollectionL.Features {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			pLimit, ok := featurePolygons(f.Geometry)
+
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+			if !ok {
+				continue
+			}
+
+			// Whatever is left of the limit once every nearby plateau is cut away is a gap
+			gaps := pLimit.Clone()
+			for _, j := range tree.search(pLimit.Bound()) {
+				if len(gaps) == 0 {
+					break
+				}
+
+				gaps = polygonDifference(gaps, orb.MultiPolygon{plateaux[j]})
+			}
+
+			if area := projection.Area(gaps); area > params[ParameterAreaTolerance] {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Layer: LayerBuildingLimits, Index: i}},
+					Geometry: gaps,
+					Message:  fmt.Sprintf("building limit %d isn't covered by the height plateaux over %.2f m²", i, area),
+				})
+			}
 		}
 
-		return true
+		return
 	})
 }
 
+// DesignRuleEngine evaluates its registry of rules. Every engine starts off with the built-in rules,
+// enabled and with their default parameters.
 type DesignRuleEngine struct {
+	rules   []*engineRule
+	byName  map[string]*engineRule
+	workers int
+	timeout time.Duration
+
+	// nil picks the UTM zone of every validation
+	projection *Projection
 }
 
-func NewDesignRuleEngine() *DesignRuleEngine {
-	return &DesignRuleEngine{}
+func NewDesignRuleEngine(opts ...DesignRuleEngineOption) *DesignRuleEngine {
+	dre := &DesignRuleEngine{
+		byName:  map[string]*engineRule{},
+		workers: runtime.GOMAXPROCS(0),
+	}
+
+	for _, rule := range sortedRules(builtinRules) {
+		dre.Register(builtinRules[rule])
+	}
+
+	for _, opt := range opts {
+		opt(dre)
+	}
+
+	return dre
 }
 
-func (dre *DesignRuleEngine) ValidateCollection(featureCollection *geojson.FeatureCollection) (ok bool, violations []error) {
-	for rule, ruleFunc := range rulesCollection {
-		if ok := ruleFunc(featureCollection); !ok {
-			violations = append(violations, errors.New(rule.String()))
-		}
+// Register adds an enabled rule to the engine. It panics if the rule is neither a CollectionRule
+// nor a SplitRule, has no valid severity or if a rule with the same name is already registered.
+func (dre *DesignRuleEngine) Register(rule Rule) {
+	switch rule.(type) {
+	case CollectionRule, SplitRule:
+	default:
+		panic(fmt.Errorf("%s is neither a collection nor a split rule", rule.Name()))
 	}
 
-	if len(violations) != 0 {
-		ok = false
-		return
+	if !rule.Severity().valid() {
+		panic(fmt.Errorf("%s has an invalid severity %q", rule.Name(), rule.Severity()))
+	}
+
+	if _, ok := dre.byName[rule.Name()]; ok {
+		panic(fmt.Errorf("%s is already registered", rule.Name()))
+	}
+
+	registered := &engineRule{Rule: rule, enabled: true, severity: rule.Severity(), params: rule.Parameters()}
+	dre.rules = append(dre.rules, registered)
+	dre.byName[rule.Name()] = registered
+}
+
+// Rules returns the registered rules in order of evaluation
+func (dre *DesignRuleEngine) Rules() []Rule {
+	rules := make([]Rule, 0, len(dre.rules))
+	for _, rule := range dre.rules {
+		rules = append(rules, rule.Rule)
+	}
+
+	return rules
+}
+
+// Settings returns the effective settings of a registered rule
+func (dre *DesignRuleEngine) Settings(name string) (settings RuleSettings, ok bool) {
+	rule, ok := dre.byName[name]
+	if !ok {
+		return RuleSettings{}, false
 	}
 
-	return true, nil
+	enabled := rule.enabled
+	return RuleSettings{Enabled: &enabled, Severity: rule.severity, Parameters: rule.params.clone()}, true
 }
 
-func (dre *DesignRuleEngine) ValidateSplits(featureCollectionL, featureCollectionP *geojson.FeatureCollection) (ok bool, violations []error) {
-	for rule, ruleFunc := range rulesSplits {
-		if ok := ruleFunc(featureCollectionL, featureCollectionP); !ok {
-			violations = append(violations, errors.New(rule.String()))
+// Configure applies the rule set on top of the current settings. Unknown rules, unknown parameters, invalid
+// severities and non-finite values are skipped and reported in the error; everything else is applied.
+func (dre *DesignRuleEngine) Configure(ruleSet RuleSet) error {
+	names := make([]string, 0, len(ruleSet))
+	for name := range ruleSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, name := range names {
+		if err := dre.configure(name, ruleSet[name]); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	if len(violations) != 0 {
-		ok = false
-		return
+	return errors.Join(errs...)
+}
+
+// ValidateCollection checks the collection rules against a single layer. It's ok as long as none of the
+// violations is an error; warnings are returned all the same.
+// Violations are ordered by rule, then by feature. An error is returned only if ctx is done first or a rule panics.
+func (dre *DesignRuleEngine) ValidateCollection(ctx context.Context, layer Layer, featureCollection *geojson.FeatureCollection) (ok bool, violations []Violation, err error) {
+	layers := map[Layer]*geojson.FeatureCollection{layer: featureCollection}
+
+	jobs := []ruleJob{}
+	for _, rule := range dre.rules {
+		collectionRule, ok := rule.Rule.(CollectionRule)
+		if !ok || !rule.enabled {
+			continue
+		}
+
+		params := rule.params.clone()
+		jobs = append(jobs, ruleJob{rule: rule.Name(), severity: rule.severity, run: func(ctx context.Context) []Violation {
+			return collectionRule.CheckCollection(ctx, params, layer, featureCollection)
+		}})
 	}
 
-	return true, nil
+	return dre.evaluate(contextWithProjection(ctx, dre.projectionOf(featureCollection)), jobs, layers, layer)
+}
+
+// ValidateSplits checks the split rules against building limits and height plateaux. It's ok as long as none of the
+// violations is an error; warnings are returned all the same.
+// Violations are ordered by rule, then by feature. An error is returned only if ctx is done first or a rule panics.
+func (dre *DesignRuleEngine) ValidateSplits(ctx context.Context, featureCollectionL, featureCollectionP *geojson.FeatureCollection) (ok bool, violations []Violation, err error) {
+	layers := map[Layer]*geojson.FeatureCollection{
+		LayerBuildingLimits: featureCollectionL,
+		LayerHeightPlateaux: featureCollectionP,
+	}
+
+	jobs := []ruleJob{}
+	for _, rule := range dre.rules {
+		splitRule, ok := rule.Rule.(SplitRule)
+		if !ok || !rule.enabled {
+			continue
+		}
+
+		params := rule.params.clone()
+		jobs = append(jobs, ruleJob{rule: rule.Name(), severity: rule.severity, run: func(ctx context.Context) []Violation {
+			return splitRule.CheckSplits(ctx, params, featureCollectionL, featureCollectionP)
+		}})
+	}
+
+	return dre.evaluate(contextWithProjection(ctx, dre.projectionOf(featureCollectionL, featureCollectionP)), jobs, layers, LayerHeightPlateaux)
 }
 
 // MARK: Private API
 
-func designRuleRegisterCollection(rule DesignRuleViolation, ruleFunc DesignRuleFuncOne) {
-	if _, ok := rulesCollection[rule]; ok {
+// projectionOf returns the projection of the engine, or else the UTM zone of the collections of a validation
+func (dre *DesignRuleEngine) projectionOf(featureCollections ...*geojson.FeatureCollection) Projection {
+	if dre.projection != nil {
+		return *dre.projection
+	}
+
+	return DetectProjection(featureCollections...)
+}
+
+// A registered rule along with its settings
+type engineRule struct {
+	Rule
+	enabled  bool
+	severity Severity
+	params   Parameters
+}
+
+// A rule bound to its input
+type ruleJob struct {
+	rule     string
+	severity Severity
+	run      func(ctx context.Context) []Violation
+}
+
+type ruleResult struct {
+	job        int
+	violations []Violation
+	err        error
+}
+
+// safeRun runs the job, turning a panic of the rule into an error so that a faulty rule can't bring the server down
+func (job ruleJob) safeRun(ctx context.Context) (violations []Violation, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			violations, err = nil, fmt.Errorf("%w: %s: %v", ErrRulePanicked, job.rule, r)
+		}
+	}()
+
+	return job.run(ctx), nil
+}
+
+// evaluate runs the jobs on a bounded pool of workers. It gives up as soon as ctx is done and cancels the ctx
+// handed to the rules; rules already running are left to notice it, their results are dropped.
+func (dre *DesignRuleEngine) evaluate(ctx context.Context, jobs []ruleJob, layers map[Layer]*geojson.FeatureCollection, defaultLayer Layer) (ok bool, violations []Violation, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if dre.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, dre.timeout)
+		defer cancel()
+	}
+
+	if err = ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	pending := make(chan int, len(jobs))
+	for i := range jobs {
+		pending <- i
+	}
+	close(pending)
+
+	// Buffered, so that abandoned workers never block
+	results := make(chan ruleResult, len(jobs))
+
+	for w := 0; w < min(dre.workers, len(jobs)); w++ {
+		go func() {
+			for i := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+
+				violations, err := jobs[i].safeRun(ctx)
+				results <- ruleResult{job: i, violations: violations, err: err}
+			}
+		}()
+	}
+
+	perJob := make([][]Violation, len(jobs))
+	errs := []error{}
+	for range jobs {
+		select {
+		case <-ctx.Done():
+			return false, nil, ctx.Err()
+		case result := <-results:
+			perJob[result.job] = result.violations
+			if result.err != nil {
+				errs = append(errs, result.err)
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return false, nil, errors.Join(errs...)
+	}
+
+	// Rules keep their order of registration, violations of a rule are ordered by feature
+	ok = true
+	for i, job := range jobs {
+		for j := range perJob[i] {
+			perJob[i][j].complete(job.rule, job.severity, layers, defaultLayer)
+		}
+
+		if len(perJob[i]) != 0 && job.severity == SeverityError {
+			ok = false
+		}
+
+		sortViolations(perJob[i])
+		violations = append(violations, perJob[i]...)
+	}
+
+	return ok, violations, nil
+}
+
+func sortedRules[F any](rules map[DesignRuleViolation]F) []DesignRuleViolation {
+	keys := make([]DesignRuleViolation, 0, len(rules))
+	for rule := range rules {
+		keys = append(keys, rule)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (dre *DesignRuleEngine) configure(name string, settings RuleSettings) error {
+	rule, ok := dre.byName[name]
+	if !ok {
+		return fmt.Errorf("unknown rule %s", name)
+	}
+
+	if settings.Enabled != nil {
+		rule.enabled = *settings.Enabled
+	}
+
+	errs := []error{}
+	if settings.Severity != "" {
+		if settings.Severity.valid() {
+			rule.severity = settings.Severity
+		} else {
+			errs = append(errs, fmt.Errorf("severity of rule %s must be %s or %s", name, SeverityError, SeverityWarning))
+		}
+	}
+
+	for parameter, value := range settings.Parameters {
+		if _, ok := rule.params[parameter]; !ok {
+			errs = append(errs, fmt.Errorf("rule %s has no parameter %s", name, parameter))
+			continue
+		}
+
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			errs = append(errs, fmt.Errorf("parameter %s of rule %s must be finite", parameter, name))
+			continue
+		}
+
+		rule.params[parameter] = value
+	}
+
+	return errors.Join(errs...)
+}
+
+// mustConfigure is meant for the built-in rules
+func (dre *DesignRuleEngine) mustConfigure(name string, settings RuleSettings) {
+	if err := dre.configure(name, settings); err != nil {
+		panic(err)
+	}
+}
+
+func designRuleRegisterCollection(rule DesignRuleViolation, severity Severity, defaults Parameters, ruleFunc DesignRuleFuncOne) {
+	if _, ok := builtinRules[rule]; ok {
 		panic(fmt.Errorf("%+v is already registered", rule))
 	}
-	rulesCollection[rule] = ruleFunc
+	builtinRules[rule] = NewCollectionRule(rule.String(), severity, defaults, ruleFunc)
 }
 
-func designRuleRegisterSplits(rule DesignRuleViolation, ruleFunc DesignRuleFuncMany) {
-	if _, ok := rulesCollection[rule]; ok {
+func designRuleRegisterSplits(rule DesignRuleViolation, severity Severity, defaults Parameters, ruleFunc DesignRuleFuncMany) {
+	if _, ok := builtinRules[rule]; ok {
 		panic(fmt.Errorf("%+v is already registered", rule))
 	}
-	rulesSplits[rule] = ruleFunc
+	builtinRules[rule] = NewSplitRule(rule.String(), severity, defaults, ruleFunc)
+}
+
+// polygonFeatures collects the polygons of the collection along with their feature indices and an R-tree
+// over their bounds. MultiPolygons contribute every component, each with the index of the feature.
+// Other geometries are taken care of by the NotPolygon rule.
+func polygonFeatures(featureCollection *geojson.FeatureCollection) (polygons []orb.Polygon, indices []int, tree *rtree) {
+	bounds := []orb.Bound{}
+
+	for i, f := range featureCollection.Features {
+		components, ok := featurePolygons(f.Geometry)
+		if !ok {
+			continue
+		}
+
+		for _, p := range components {
+			polygons = append(polygons, p)
+			indices = append(indices, i)
+			bounds = append(bounds, p.Bound())
+		}
+	}
+
+	return polygons, indices, newRTree(bounds)
+}
+
+// featurePolygons returns the components of a Polygon or MultiPolygon geometry
+func featurePolygons(geometry orb.Geometry) (polygons orb.MultiPolygon, ok bool) {
+	switch g := geometry.(type) {
+	case orb.Polygon:
+		return orb.MultiPolygon{g}, true
+	case orb.MultiPolygon:
+		return g, true
+	}
+
+	return nil, false
+}
+
+// singlePolygon unwraps a MultiPolygon of a single component
+func singlePolygon(polygons orb.MultiPolygon) orb.Geometry {
+	if len(polygons) == 1 {
+		return polygons[0]
+	}
+
+	return polygons
+}
+
+// polygonsOverlapped reports whether the polygons share more than tolerance of area, along with the shared area.
+// The overlay covers both crossing edges and containment, whereas polygons
+// touching along an edge or at a vertex share no area at all.
+func polygonsOverlapped(polygonA, polygonB orb.Polygon, tolerance float64) (overlap orb.MultiPolygon, overlapped bool) {
+	if !polygonA.Bound().Intersects(polygonB.Bound()) {
+		return nil, false
+	}
+
+	overlap = polygonIntersection(orb.MultiPolygon{polygonA}, orb.MultiPolygon{polygonB})
+
+	return overlap, planar.Area(overlap) > tolerance
+}
+
+// polygonThinness returns 4πA/P², which is 1 for a circle and tends to 0 for slivers.
+// Degenerate polygons without a perimeter are considered round.
+func polygonThinness(polygon orb.Polygon) float64 {
+	perimeter := planar.Length(polygon)
+	if perimeter == 0 {
+		return 1
+	}
+
+	return 4 * math.Pi * planar.Area(polygon) / (perimeter * perimeter)
+}
+
+// multiPolygonSelfIntersections returns the coordinates where any component isn't simple
+// along with those where components cross each other. A component lying within another
+// one is reported at its first vertex; components may only touch.
+func multiPolygonSelfIntersections(polygons orb.MultiPolygon) (coordinates []orb.Point) {
+	for i, p := range polygons {
+		coordinates = append(coordinates, polygonSelfIntersections(p)...)
+
+		for _, q := range polygons[i+1:] {
+			if len(p) == 0 || len(q) == 0 {
+				continue
+			}
+
+			if _, overlapped := polygonsOverlapped(p, q, 0); !overlapped {
+				continue
+			}
+
+			crossings := ringsCrossings(distinctRing(p[0]), distinctRing(q[0]))
+			if len(crossings) == 0 && len(q[0]) != 0 && len(p[0]) != 0 {
+				if planar.RingContains(p[0], q[0][0]) {
+					crossings = append(crossings, q[0][0])
+				} else {
+					crossings = append(crossings, p[0][0])
+				}
+			}
+
+			coordinates = append(coordinates, crossings...)
+		}
+	}
+
+	return uniquePoints(coordinates)
 }
 
-func polygonClosed(polygon orb.Polygon) bool {
+// polygonSelfIntersections returns the coordinates where the polygon isn't simple:
+// bow-tie rings, shell and holes crossing each other and holes outside of the shell.
+func polygonSelfIntersections(polygon orb.Polygon) (coordinates []orb.Point) {
+	rings := make([]orb.Ring, 0, len(polygon))
 	for _, ring := range polygon {
-		if !ring.Closed() {
-			return false
+		rings = append(rings, distinctRing(ring))
+	}
+
+	for i, ring := range rings {
+		coordinates = append(coordinates, ringSelfIntersections(ring)...)
+
+		for j := i + 1; j < len(rings); j++ {
+			coordinates = append(coordinates, ringsCrossings(ring, rings[j])...)
+		}
+	}
+
+	// Holes must lie within the shell
+	for _, hole := range rings[min(1, len(rings)):] {
+		for _, p := range hole {
+			if !planar.RingContains(rings[0], p) {
+				coordinates = append(coordinates, p)
+				break
+			}
+		}
+	}
+
+	return uniquePoints(coordinates)
+}
+
+// ringSelfIntersections returns the coordinates where non-adjacent edges of the ring meet
+// or adjacent edges fold back onto each other. The ring must be distinct.
+func ringSelfIntersections(ring orb.Ring) (coordinates []orb.Point) {
+	n := len(ring)
+
+	for i := 0; i < n; i++ {
+		a1, a2 := ring[i], ring[(i+1)%n]
+
+		for j := i + 1; j < n; j++ {
+			b1, b2 := ring[j], ring[(j+1)%n]
+			points, _ := segmentsIntersection(a1, a2, b1, b2)
+
+			adjacent := j == i+1 || (i == 0 && j == n-1)
+			if adjacent {
+				// Adjacent edges share a vertex; anything beyond that is a spike
+				if len(points) > 1 {
+					coordinates = append(coordinates, b1)
+				}
+				continue
+			}
+
+			coordinates = append(coordinates, points...)
+		}
+	}
+
+	return
+}
+
+// ringsCrossings returns the coordinates where the edges of both rings cross.
+// Rings are allowed to touch at a single point.
+func ringsCrossings(ringA, ringB orb.Ring) (coordinates []orb.Point) {
+	if !ringA.Bound().Intersects(ringB.Bound()) {
+		return nil
+	}
+
+	for i := range ringA {
+		a1, a2 := ringA[i], ringA[(i+1)%len(ringA)]
+
+		for j := range ringB {
+			b1, b2 := ringB[j], ringB[(j+1)%len(ringB)]
+
+			if points, proper := segmentsIntersection(a1, a2, b1, b2); proper {
+				coordinates = append(coordinates, points...)
+			}
 		}
 	}
 
-	return true
+	return
 }
 
-func polygonsOverlapped(polygonA, polygonB orb.Polygon) bool {
-	// Check if any part of polygonA intersects with polygonB
-	clipped := clip.Polygon(polygonB.Bound(), polygonA.Clone())
-	if len(clipped) > 0 {
-		return true
+// segmentsIntersection returns the points shared by segments a and b. The intersection is
+// proper if the segments cross each other's interior or overlap along a stretch.
+func segmentsIntersection(a1, a2, b1, b2 orb.Point) (points []orb.Point, proper bool) {
+	r := sub(a2, a1)
+	q := sub(b2, b1)
+	lengthR := math.Hypot(r[0], r[1])
+	lengthQ := math.Hypot(q[0], q[1])
+
+	if lengthR == 0 || lengthQ == 0 {
+		return nil, false
 	}
 
-	// Check if polygonB is entirely within polygonA
-	if planar.PolygonContains(polygonA, polygonB[0][0]) {
-		return true
+	qp := sub(b1, a1)
+	denominator := cross(r, q)
+	toleranceT := overlayEpsilon / lengthR
+
+	// Parallel segments
+	if math.Abs(denominator) <= 1e-12*lengthR*lengthQ {
+		if math.Abs(cross(qp, r))/lengthR > overlayEpsilon {
+			return nil, false
+		}
+
+		// Collinear segments, project b onto a
+		rr := dot(r, r)
+		t0 := dot(qp, r) / rr
+		t1 := dot(sub(b2, a1), r) / rr
+		lo := math.Max(0, math.Min(t0, t1))
+		hi := math.Min(1, math.Max(t0, t1))
+
+		if lo > hi+toleranceT {
+			return nil, false
+		}
+
+		pLo := orb.Point{a1[0] + lo*r[0], a1[1] + lo*r[1]}
+		if hi-lo <= toleranceT {
+			return []orb.Point{pLo}, false
+		}
+
+		return []orb.Point{pLo, {a1[0] + hi*r[0], a1[1] + hi*r[1]}}, true
 	}
 
-	// Check if polygonA is entirely within polygonB
-	if planar.PolygonContains(polygonB, polygonA[0][0]) {
-		return true
+	t := cross(qp, q) / denominator
+	u := cross(qp, r) / denominator
+	toleranceU := overlayEpsilon / lengthQ
+
+	if t < -toleranceT || t > 1+toleranceT || u < -toleranceU || u > 1+toleranceU {
+		return nil, false
+	}
+
+	proper = t > toleranceT && t < 1-toleranceT && u > toleranceU && u < 1-toleranceU
+
+	return []orb.Point{{a1[0] + t*r[0], a1[1] + t*r[1]}}, proper
+}
+
+// distinctRing returns the open ring without consecutive duplicate points
+func distinctRing(ring orb.Ring) orb.Ring {
+	distinct := orb.Ring{}
+
+	for _, p := range openRing(ring) {
+		if len(distinct) > 0 && distinct[len(distinct)-1].Equal(p) {
+			continue
+		}
+		distinct = append(distinct, p)
+	}
+
+	if len(distinct) > 1 && distinct[0].Equal(distinct[len(distinct)-1]) {
+		distinct = distinct[:len(distinct)-1]
+	}
+
+	return distinct
+}
+
+func uniquePoints(points []orb.Point) (unique []orb.Point) {
+	seen := map[orb.Point]bool{}
+
+	for _, p := range points {
+		if seen[p] {
+			continue
+		}
+
+		seen[p] = true
+		unique = append(unique, p)
 	}
 
-	return false
+	return
 }
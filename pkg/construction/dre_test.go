@@ -0,0 +1,103 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Every built-in rule blocks a write by default, except for slivers
+func TestDefaultSeverities(t *testing.T) {
+	want := map[string]Severity{
+		DesignRuleViolationOverlapped.String():       SeverityError,
+		DesignRuleViolationNotClosed.String():        SeverityError,
+		DesignRuleViolationNotPolygon.String():       SeverityError,
+		DesignRuleViolationSelfIntersection.String(): SeverityError,
+		DesignRuleViolationSliver.String():           SeverityWarning,
+		DesignRuleViolationPropertySchema.String():   SeverityError,
+		DesignRuleViolationWindingOrder.String():     SeverityError,
+		DesignRuleViolationCoordinateRange.String():  SeverityError,
+		DesignRuleViolationOutOfBound.String():       SeverityError,
+		DesignRuleViolationNotCovered.String():       SeverityError,
+	}
+
+	rules := NewDesignRuleEngine().Rules()
+	if len(rules) != len(want) {
+		t.Errorf("got %d built-in rules, want %d", len(rules), len(want))
+	}
+
+	for _, rule := range rules {
+		t.Run(rule.Name(), func(t *testing.T) {
+			severity, ok := want[rule.Name()]
+			if !ok {
+				t.Fatal("unexpected built-in rule")
+			}
+
+			if rule.Severity() != severity {
+				t.Errorf("got %s, want %s", rule.Severity(), severity)
+			}
+		})
+	}
+}
+
+// Violations come in the order of the rules, then of the features, however the rules are scheduled
+func TestViolationOrder(t *testing.T) {
+	featureCollection := geojson.NewFeatureCollection()
+	for _, polygon := range []orb.Polygon{
+		overlayTestSquare(10, 60, 10.006, 60.003),
+		overlayTestSquare(10.005, 60.001, 10.007, 60.002),
+		overlayTestSquare(9.999, 60.001, 10.001, 60.002),
+		overlayTestSquare(10.003, 60.002, 10.004, 60.004),
+		{{{10, 61}, {10.001, 61}, {10.001, 61.001}}},
+		{{{10.01, 60}, {10.01, 60.001}, {10.011, 60.001}, {10.011, 60}, {10.01, 60}}},
+	} {
+		featureCollection.Append(geojson.NewFeature(polygon))
+	}
+
+	want := []string{
+		"DesignRuleViolationOverlapped: features 0 and 1 overlap",
+		"DesignRuleViolationOverlapped: features 0 and 2 overlap",
+		"DesignRuleViolationOverlapped: features 0 and 3 overlap",
+		"DesignRuleViolationNotClosed: feature 4 has a ring which isn't closed",
+		"DesignRuleViolationWindingOrder: feature 5 has 1 ring(s) wound against RFC 7946, exterior rings must be counter-clockwise and holes clockwise",
+	}
+
+	for i := 0; i < 20; i++ {
+		_, violations, err := NewDesignRuleEngine(WithConcurrency(8)).ValidateCollection(context.Background(), LayerBuildingLimits, featureCollection)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, violation := range violations {
+			got = append(got, violation.Error())
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+// Tolerances stored in square degrees, before areas were measured in square metres, mustn't be read as square metres
+func TestLegacyAreaTolerance(t *testing.T) {
+	ruleSet := RuleSet{DesignRuleViolationOverlapped.String(): {Parameters: Parameters{"area_tolerance": 1e-8}}}
+
+	if err := NewDesignRuleEngine().Configure(ruleSet); err == nil {
+		t.Error("Configure: got no error, want the legacy parameter rejected")
+	}
+
+	settings, _ := NewDesignRuleEngine(WithRuleSet(ruleSet)).Settings(DesignRuleViolationOverlapped.String())
+	if got := settings.Parameters[ParameterAreaTolerance]; got != 0 {
+		t.Errorf("WithRuleSet: got %s %v, want 0", ParameterAreaTolerance, got)
+	}
+}
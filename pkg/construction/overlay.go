@@ -0,0 +1,557 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Polygon overlay (intersection, union and difference) for orb geometries.
+//
+// orb doesn't ship boolean operations on polygons, hence the home-made one.
+// The algorithm is the classic planar overlay:
+//   1. orient every ring so that the interior is on the left of each edge
+//   2. split the edges of both operands at their mutual intersections
+//   3. keep the edges which bound the result (Martinez-Rueda selection rules)
+//   4. trace the kept edges back into rings and assemble the polygons
+//
+// Ref: https://en.wikipedia.org/wiki/Boolean_operations_on_polygons
+
+type overlayOp int
+
+const (
+	overlayIntersection overlayOp = iota
+	overlayUnion
+	overlayDifference
+)
+
+// Two points closer than overlayEpsilon (in coordinate units) are the same vertex.
+// 1e-10 degrees is roughly 0.01 mm on the ground.
+const overlayEpsilon = 1e-10
+
+// polygonIntersection returns the area covered by both a and b
+func polygonIntersection(a, b orb.MultiPolygon) orb.MultiPolygon {
+	if !a.Bound().Intersects(b.Bound()) {
+		return orb.MultiPolygon{}
+	}
+
+	return overlay(a, b, overlayIntersection)
+}
+
+// polygonUnion returns the area covered by either a or b
+func polygonUnion(a, b orb.MultiPolygon) orb.MultiPolygon {
+	if len(a) == 0 {
+		return b.Clone()
+	}
+
+	if len(b) == 0 {
+		return a.Clone()
+	}
+
+	return overlay(a, b, overlayUnion)
+}
+
+// polygonDifference returns the area covered by a but not by b
+func polygonDifference(a, b orb.MultiPolygon) orb.MultiPolygon {
+	if len(b) == 0 || !a.Bound().Intersects(b.Bound()) {
+		return a.Clone()
+	}
+
+	return overlay(a, b, overlayDifference)
+}
+
+// MARK: Overlay
+
+type overlayOwner int
+
+const (
+	ownerA overlayOwner = iota
+	ownerB
+)
+
+type overlaySegment struct {
+	from, to orb.Point
+}
+
+type overlayEdge struct {
+	from, to int
+}
+
+// overlayGraph keeps the vertex registry shared by both operands so that
+// split points computed from either side snap onto the same vertex.
+type overlayGraph struct {
+	vertices []orb.Point
+	grid     map[[2]int64][]int
+}
+
+func overlay(a, b orb.MultiPolygon, op overlayOp) orb.MultiPolygon {
+	g := &overlayGraph{grid: map[[2]int64][]int{}}
+
+	segmentsA := orientedSegments(a)
+	segmentsB := orientedSegments(b)
+
+	edgesA := g.split(segmentsA, segmentsB)
+	edgesB := g.split(segmentsB, segmentsA)
+
+	// Index the edges of each operand by their undirected key to spot shared edges
+	sharedA := map[[2]int]overlayEdge{}
+	for _, e := range edgesA {
+		sharedA[undirectedKey(e)] = e
+	}
+
+	sharedB := map[[2]int]overlayEdge{}
+	for _, e := range edgesB {
+		sharedB[undirectedKey(e)] = e
+	}
+
+	result := map[overlayEdge]bool{}
+	keep := func(e overlayEdge) {
+		// Opposite edges cancel each other out
+		if result[overlayEdge{e.to, e.from}] {
+			delete(result, overlayEdge{e.to, e.from})
+			return
+		}
+		result[e] = true
+	}
+
+	for _, e := range edgesA {
+		if other, shared := sharedB[undirectedKey(e)]; shared {
+			sameDirection := other.from == e.from
+
+			switch op {
+			case overlayIntersection, overlayUnion:
+				if sameDirection {
+					keep(e)
+				}
+			case overlayDifference:
+				if !sameDirection {
+					keep(e)
+				}
+			}
+
+			continue
+		}
+
+		inside := planar.MultiPolygonContains(b, g.midpoint(e))
+
+		switch op {
+		case overlayIntersection:
+			if inside {
+				keep(e)
+			}
+		case overlayUnion, overlayDifference:
+			if !inside {
+				keep(e)
+			}
+		}
+	}
+
+	for _, e := range edgesB {
+		// Shared edges are taken care of above
+		if _, shared := sharedA[undirectedKey(e)]; shared {
+			continue
+		}
+
+		inside := planar.MultiPolygonContains(a, g.midpoint(e))
+
+		switch op {
+		case overlayIntersection:
+			if inside {
+				keep(e)
+			}
+		case overlayUnion:
+			if !inside {
+				keep(e)
+			}
+		case overlayDifference:
+			if inside {
+				keep(overlayEdge{e.to, e.from})
+			}
+		}
+	}
+
+	return g.assemble(result)
+}
+
+// orientedSegments returns the edges of every ring with the interior on their left:
+// shells are counter-clockwise and holes are clockwise.
+func orientedSegments(mp orb.MultiPolygon) (segments []overlaySegment) {
+	for _, polygon := range mp {
+		for i, ring := range polygon {
+			points := openRing(ring)
+			if len(points) < 3 {
+				continue
+			}
+
+			area := signedArea(points)
+			if area == 0 {
+				continue
+			}
+
+			// Shell must be CCW (positive), hole must be CW (negative)
+			reverse := (i == 0) != (area > 0)
+
+			for j := range points {
+				from, to := points[j], points[(j+1)%len(points)]
+				if reverse {
+					from, to = to, from
+				}
+
+				if from.Equal(to) {
+					continue
+				}
+
+				segments = append(segments, overlaySegment{from, to})
+			}
+		}
+	}
+
+	return
+}
+
+// split cuts every segment of subject at the points where it meets any segment of clip
+func (g *overlayGraph) split(subject, clip []overlaySegment) (edges []overlayEdge) {
+	type cut struct {
+		t     float64
+		point orb.Point
+	}
+
+	clipBounds := make([]orb.Bound, len(clip))
+	for i, s := range clip {
+		clipBounds[i] = segmentBound(s)
+	}
+
+	for _, s := range subject {
+		bound := segmentBound(s).Pad(overlayEpsilon)
+		cuts := []cut{}
+
+		for i, c := range clip {
+			if !bound.Intersects(clipBounds[i]) {
+				continue
+			}
+
+			for _, p := range segmentCuts(s, c) {
+				cuts = append(cuts, cut{segmentParameter(s, p), p})
+			}
+		}
+
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i].t < cuts[j].t })
+
+		from := g.vertex(s.from)
+		for _, c := range cuts {
+			to := g.vertex(c.point)
+			if to != from {
+				edges = append(edges, overlayEdge{from, to})
+				from = to
+			}
+		}
+
+		if to := g.vertex(s.to); to != from {
+			edges = append(edges, overlayEdge{from, to})
+		}
+	}
+
+	return
+}
+
+// segmentCuts returns the points strictly inside s where it is crossed or touched by c
+func segmentCuts(s, c overlaySegment) (cuts []orb.Point) {
+	r := sub(s.to, s.from)
+	q := sub(c.to, c.from)
+	lengthR := math.Hypot(r[0], r[1])
+	lengthQ := math.Hypot(q[0], q[1])
+
+	if lengthR == 0 || lengthQ == 0 {
+		return nil
+	}
+
+	qp := sub(c.from, s.from)
+	denominator := cross(r, q)
+
+	// interior reports whether p lies on s but not on one of its end points
+	interior := func(p orb.Point) bool {
+		return planar.Distance(p, s.from) > overlayEpsilon &&
+			planar.Distance(p, s.to) > overlayEpsilon &&
+			planar.DistanceFromSegment(s.from, s.to, p) <= overlayEpsilon
+	}
+
+	// Parallel segments
+	if math.Abs(denominator) <= 1e-12*lengthR*lengthQ {
+		// Collinear segments are cut by the end points of the other one
+		for _, p := range []orb.Point{c.from, c.to} {
+			if interior(p) {
+				cuts = append(cuts, p)
+			}
+		}
+
+		return cuts
+	}
+
+	t := cross(qp, q) / denominator
+	u := cross(qp, r) / denominator
+	toleranceT := overlayEpsilon / lengthR
+	toleranceU := overlayEpsilon / lengthQ
+
+	if t < -toleranceT || t > 1+toleranceT || u < -toleranceU || u > 1+toleranceU {
+		return nil
+	}
+
+	// Prefer existing vertices over computed ones to keep the topology intact
+	var p orb.Point
+	switch {
+	case u <= toleranceU:
+		p = c.from
+	case u >= 1-toleranceU:
+		p = c.to
+	default:
+		p = orb.Point{s.from[0] + t*r[0], s.from[1] + t*r[1]}
+	}
+
+	if interior(p) {
+		cuts = append(cuts, p)
+	}
+
+	return cuts
+}
+
+// vertex registers the point and returns its index, reusing any vertex closer than overlayEpsilon
+func (g *overlayGraph) vertex(p orb.Point) int {
+	cx := int64(math.Floor(p[0] / overlayEpsilon))
+	cy := int64(math.Floor(p[1] / overlayEpsilon))
+
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, i := range g.grid[[2]int64{cx + dx, cy + dy}] {
+				if planar.Distance(g.vertices[i], p) <= overlayEpsilon {
+					return i
+				}
+			}
+		}
+	}
+
+	g.vertices = append(g.vertices, p)
+	i := len(g.vertices) - 1
+	g.grid[[2]int64{cx, cy}] = append(g.grid[[2]int64{cx, cy}], i)
+
+	return i
+}
+
+func (g *overlayGraph) midpoint(e overlayEdge) orb.Point {
+	from, to := g.vertices[e.from], g.vertices[e.to]
+	return orb.Point{(from[0] + to[0]) / 2, (from[1] + to[1]) / 2}
+}
+
+// assemble traces the directed edges into rings and groups them into polygons
+func (g *overlayGraph) assemble(edges map[overlayEdge]bool) orb.MultiPolygon {
+	outgoing := map[int][]int{}
+	for e := range edges {
+		outgoing[e.from] = append(outgoing[e.from], e.to)
+	}
+
+	// Deterministic traversal
+	starts := make([]overlayEdge, 0, len(edges))
+	for e := range edges {
+		starts = append(starts, e)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		if starts[i].from != starts[j].from {
+			return starts[i].from < starts[j].from
+		}
+		return starts[i].to < starts[j].to
+	})
+
+	used := map[overlayEdge]bool{}
+	shells := []orb.Ring{}
+	holes := []orb.Ring{}
+
+	for _, start := range starts {
+		if used[start] {
+			continue
+		}
+
+		ring := orb.Ring{g.vertices[start.from]}
+		current := start
+		used[current] = true
+		closed := false
+
+		for {
+			ring = append(ring, g.vertices[current.to])
+
+			if current.to == start.from {
+				closed = true
+				break
+			}
+
+			next, ok := g.nextEdge(current, outgoing[current.to], used)
+			if !ok {
+				break
+			}
+
+			used[next] = true
+			current = next
+		}
+
+		if !closed || len(ring) < 4 {
+			continue
+		}
+
+		area := signedArea(openRing(ring))
+		if math.Abs(area) <= overlayEpsilon*overlayEpsilon {
+			continue
+		}
+
+		if area > 0 {
+			shells = append(shells, ring)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	polygons := make(orb.MultiPolygon, len(shells))
+	for i, shell := range shells {
+		polygons[i] = orb.Polygon{shell}
+	}
+
+	// Every hole goes to the smallest shell containing it
+	for _, hole := range holes {
+		owner := -1
+		ownerArea := math.Inf(1)
+
+		for i, shell := range shells {
+			if !ringWithin(hole, shell) {
+				continue
+			}
+
+			if area := signedArea(openRing(shell)); area < ownerArea {
+				owner, ownerArea = i, area
+			}
+		}
+
+		if owner >= 0 {
+			polygons[owner] = append(polygons[owner], hole)
+		}
+	}
+
+	return polygons
+}
+
+// nextEdge picks the first unused outgoing edge clockwise from the reversed incoming edge,
+// which keeps the traced face on the left.
+func (g *overlayGraph) nextEdge(incoming overlayEdge, candidates []int, used map[overlayEdge]bool) (overlayEdge, bool) {
+	vertex := g.vertices[incoming.to]
+	back := g.vertices[incoming.from]
+	base := math.Atan2(back[1]-vertex[1], back[0]-vertex[0])
+
+	best := overlayEdge{}
+	bestDelta := math.Inf(1)
+	found := false
+
+	for _, to := range candidates {
+		e := overlayEdge{incoming.to, to}
+		if used[e] {
+			continue
+		}
+
+		p := g.vertices[to]
+		delta := base - math.Atan2(p[1]-vertex[1], p[0]-vertex[0])
+		for delta <= 0 {
+			delta += 2 * math.Pi
+		}
+
+		if delta < bestDelta {
+			best, bestDelta, found = e, delta, true
+		}
+	}
+
+	return best, found
+}
+
+// MARK: Helpers
+
+// openRing returns the ring without the closing point
+func openRing(ring orb.Ring) orb.Ring {
+	if len(ring) > 1 && ring[0].Equal(ring[len(ring)-1]) {
+		return ring[:len(ring)-1]
+	}
+
+	return ring
+}
+
+// signedArea is positive for counter-clockwise rings. The ring must be open.
+func signedArea(ring orb.Ring) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+
+	area := 0.0
+	origin := ring[0]
+	for i := 1; i < len(ring)-1; i++ {
+		area += cross(sub(ring[i], origin), sub(ring[i+1], origin))
+	}
+
+	return area / 2
+}
+
+// ringWithin reports whether every vertex of inner lies inside or on outer
+func ringWithin(inner, outer orb.Ring) bool {
+	if !outer.Bound().Pad(overlayEpsilon).Intersects(inner.Bound()) {
+		return false
+	}
+
+	for _, p := range inner {
+		if !planar.RingContains(outer, p) && !onRing(outer, p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// onRing reports whether p lies on the boundary of the ring within overlayEpsilon
+func onRing(ring orb.Ring, p orb.Point) bool {
+	for i := 0; i < len(ring); i++ {
+		if planar.DistanceFromSegment(ring[i], ring[(i+1)%len(ring)], p) <= overlayEpsilon {
+			return true
+		}
+	}
+
+	return false
+}
+
+func segmentBound(s overlaySegment) orb.Bound {
+	return orb.Bound{Min: s.from, Max: s.from}.Extend(s.to)
+}
+
+// segmentParameter returns the position of p along s, 0 at s.from and 1 at s.to
+func segmentParameter(s overlaySegment, p orb.Point) float64 {
+	r := sub(s.to, s.from)
+	return dot(sub(p, s.from), r) / dot(r, r)
+}
+
+func undirectedKey(e overlayEdge) [2]int {
+	if e.from < e.to {
+		return [2]int{e.from, e.to}
+	}
+
+	return [2]int{e.to, e.from}
+}
+
+func sub(a, b orb.Point) orb.Point {
+	return orb.Point{a[0] - b[0], a[1] - b[1]}
+}
+
+func cross(a, b orb.Point) float64 {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+func dot(a, b orb.Point) float64 {
+	return a[0]*b[0] + a[1]*b[1]
+}
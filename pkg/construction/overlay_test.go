@@ -0,0 +1,232 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// L-shape of area 3 with its notch at [1, 2] × [1, 2]
+var overlayTestL = orb.Polygon{{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 0}}}
+
+func TestOverlay(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b orb.Polygon
+
+		// Areas of the intersection, the union and the difference a - b
+		intersection, union, difference float64
+
+		// Rings making up the union and the difference
+		unionRings, differenceRings int
+	}{
+		{
+			name: "disjoint",
+			a:    overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(2, 0, 3, 1),
+			intersection: 0, union: 2, difference: 1,
+			unionRings: 2, differenceRings: 1,
+		},
+		{
+			name: "identical",
+			a:    overlayTestSquare(0, 0, 2, 2), b: overlayTestSquare(0, 0, 2, 2),
+			intersection: 4, union: 4, difference: 0,
+			unionRings: 1, differenceRings: 0,
+		},
+		{
+			name: "shared edge in opposite directions",
+			a:    overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(1, 0, 2, 1),
+			intersection: 0, union: 2, difference: 1,
+			unionRings: 1, differenceRings: 1,
+		},
+		{
+			name: "shared edge in the same direction",
+			a:    overlayTestSquare(0, 0, 2, 2), b: overlayTestSquare(0.5, 0, 1.5, 1),
+			intersection: 1, union: 4, difference: 3,
+			unionRings: 1, differenceRings: 1,
+		},
+		{
+			name: "touching at a vertex",
+			a:    overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(1, 1, 2, 2),
+			intersection: 0, union: 2, difference: 1,
+			unionRings: 2, differenceRings: 1,
+		},
+		{
+			name: "L-shape and a square across its notch",
+			a:    overlayTestL, b: overlayTestSquare(0.5, 0.5, 1.5, 1.5),
+			intersection: 0.75, union: 3.25, difference: 2.25,
+			unionRings: 1, differenceRings: 1,
+		},
+		{
+			name: "L-shape and the square filling its notch",
+			a:    overlayTestL, b: overlayTestSquare(1, 1, 2, 2),
+			intersection: 0, union: 4, difference: 3,
+			unionRings: 1, differenceRings: 1,
+		},
+		{
+			name: "containment",
+			a:    overlayTestSquare(0, 0, 4, 4), b: overlayTestSquare(1, 1, 2, 2),
+			intersection: 1, union: 16, difference: 15,
+			unionRings: 1, differenceRings: 2,
+		},
+		{
+			name: "overlapping a hole",
+			a:    overlayTestSquareWithHole(0, 0, 4, 4, 1, 1, 3, 3), b: overlayTestSquare(2, 2, 5, 5),
+			intersection: 3, union: 18, difference: 9,
+			unionRings: 2, differenceRings: 1,
+		},
+		{
+			name: "within a hole",
+			a:    overlayTestSquareWithHole(0, 0, 4, 4, 1, 1, 3, 3), b: overlayTestSquare(1.5, 1.5, 2.5, 2.5),
+			intersection: 0, union: 13, difference: 12,
+			unionRings: 3, differenceRings: 2,
+		},
+		{
+			name: "collinear overlaps",
+			a:    overlayTestSquare(0, 0, 2, 2), b: overlayTestSquare(1, 0, 3, 2),
+			intersection: 2, union: 6, difference: 2,
+			unionRings: 1, differenceRings: 1,
+		},
+		{
+			name: "collinear overlap of a partial edge",
+			a:    overlayTestSquare(0, 0, 2, 1), b: overlayTestSquare(1, 0, 3, 2),
+			intersection: 1, union: 5, difference: 1,
+			unionRings: 1, differenceRings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := orb.MultiPolygon{tt.a}, orb.MultiPolygon{tt.b}
+
+			for _, op := range []struct {
+				name   string
+				result orb.MultiPolygon
+				area   float64
+				rings  int
+			}{
+				{"intersection", polygonIntersection(a, b), tt.intersection, -1},
+				{"reversed intersection", polygonIntersection(b, a), tt.intersection, -1},
+				{"union", polygonUnion(a, b), tt.union, tt.unionRings},
+				{"reversed union", polygonUnion(b, a), tt.union, tt.unionRings},
+				{"difference", polygonDifference(a, b), tt.difference, tt.differenceRings},
+			} {
+				if area := planar.Area(op.result); math.Abs(area-op.area) > 1e-9 {
+					t.Errorf("%s: got area %v, want %v", op.name, area, op.area)
+				}
+
+				if rings := overlayTestRings(op.result); op.rings >= 0 && rings != op.rings {
+					t.Errorf("%s: got %d rings, want %d: %v", op.name, rings, op.rings, op.result)
+				}
+
+				overlayTestAssertWinding(t, op.name, op.result)
+			}
+		})
+	}
+}
+
+func TestSegmentCuts(t *testing.T) {
+	s := overlaySegment{orb.Point{0, 0}, orb.Point{2, 0}}
+
+	tests := []struct {
+		name string
+		c    overlaySegment
+		want []orb.Point
+	}{
+		{name: "crossing", c: overlaySegment{orb.Point{1, -1}, orb.Point{1, 1}}, want: []orb.Point{{1, 0}}},
+		{name: "touching with an end point", c: overlaySegment{orb.Point{1, 0}, orb.Point{1, 1}}, want: []orb.Point{{1, 0}}},
+		{name: "touching an end point", c: overlaySegment{orb.Point{2, -1}, orb.Point{2, 1}}},
+		{name: "sharing an end point", c: overlaySegment{orb.Point{2, 0}, orb.Point{3, 1}}},
+		{name: "collinear overlap", c: overlaySegment{orb.Point{1, 0}, orb.Point{3, 0}}, want: []orb.Point{{1, 0}}},
+		{name: "collinear within", c: overlaySegment{orb.Point{0.5, 0}, orb.Point{1.5, 0}}, want: []orb.Point{{0.5, 0}, {1.5, 0}}},
+		{name: "collinear cover", c: overlaySegment{orb.Point{-1, 0}, orb.Point{3, 0}}},
+		{name: "collinear disjoint", c: overlaySegment{orb.Point{3, 0}, orb.Point{4, 0}}},
+		{name: "parallel", c: overlaySegment{orb.Point{0, 1}, orb.Point{2, 1}}},
+		{name: "missing", c: overlaySegment{orb.Point{3, -1}, orb.Point{3, 1}}},
+		{name: "degenerate", c: overlaySegment{orb.Point{1, 0}, orb.Point{1, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := segmentCuts(s, tt.c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonsOverlapped(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      orb.Polygon
+		tolerance float64
+		want      bool
+	}{
+		{name: "shared edge", a: overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(1, 0, 2, 1)},
+		{name: "touching at a vertex", a: overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(1, 1, 2, 2)},
+		{name: "crossing", a: overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(0.5, 0.5, 1.5, 1.5), want: true},
+		{name: "crossing within tolerance", a: overlayTestSquare(0, 0, 1, 1), b: overlayTestSquare(0.5, 0.5, 1.5, 1.5), tolerance: 0.5},
+		{name: "containment", a: overlayTestSquare(0, 0, 4, 4), b: overlayTestSquare(1, 1, 2, 2), want: true},
+		{name: "within a hole", a: overlayTestSquareWithHole(0, 0, 4, 4, 1, 1, 3, 3), b: overlayTestSquare(1.5, 1.5, 2.5, 2.5)},
+		{name: "L-shape and the square filling its notch", a: overlayTestL, b: overlayTestSquare(1, 1, 2, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := polygonsOverlapped(tt.a, tt.b, tt.tolerance); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			if _, got := polygonsOverlapped(tt.b, tt.a, tt.tolerance); got != tt.want {
+				t.Errorf("reversed: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// MARK: Private API
+
+// overlayTestSquare returns a counter-clockwise rectangle
+func overlayTestSquare(minX, minY, maxX, maxY float64) orb.Polygon {
+	return orb.Polygon{{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY}}}
+}
+
+func overlayTestSquareWithHole(minX, minY, maxX, maxY, holeMinX, holeMinY, holeMaxX, holeMaxY float64) orb.Polygon {
+	hole := overlayTestSquare(holeMinX, holeMinY, holeMaxX, holeMaxY)[0]
+	hole.Reverse()
+
+	return orb.Polygon{overlayTestSquare(minX, minY, maxX, maxY)[0], hole}
+}
+
+func overlayTestRings(polygons orb.MultiPolygon) (rings int) {
+	for _, polygon := range polygons {
+		rings += len(polygon)
+	}
+
+	return rings
+}
+
+// overlayTestAssertWinding checks that the result is made of closed counter-clockwise shells and clockwise holes
+func overlayTestAssertWinding(t *testing.T, name string, polygons orb.MultiPolygon) {
+	t.Helper()
+
+	for i, polygon := range polygons {
+		for j, ring := range polygon {
+			if !ring.Closed() {
+				t.Errorf("%s: ring %d of polygon %d isn't closed", name, j, i)
+			}
+
+			if area := signedArea(openRing(ring)); (j == 0) != (area > 0) {
+				t.Errorf("%s: ring %d of polygon %d is wound the wrong way", name, j, i)
+			}
+		}
+	}
+}
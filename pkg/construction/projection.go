@@ -0,0 +1,192 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/orb/project"
+)
+
+// EPSG codes of the UTM zones, add the zone number, e.g. 32600 + 32 = 32632
+const (
+	EPSGWGS84UTMNorth  = 32600
+	EPSGWGS84UTMSouth  = 32700
+	EPSGETRS89UTMNorth = 25800 // zones 28 to 38 only, across Europe
+)
+
+// Projection maps WGS84 longitudes and latitudes onto a local metric plane, a UTM zone,
+// so that areas and lengths come out in square metres and metres
+type Projection struct {
+	EPSG int
+
+	zone       int
+	south      bool
+	flattening float64
+}
+
+// Measurement is the size of a feature in the metric plane of a projection
+type Measurement struct {
+	Index     int     `json:"index"`
+	ID        any     `json:"id,omitempty"`
+	Area      float64 `json:"area"`      // square metres
+	Perimeter float64 `json:"perimeter"` // metres, holes included
+}
+
+// NewProjection returns the projection of the given EPSG code, either a WGS84 or an ETRS89 UTM zone
+func NewProjection(epsg int) (Projection, error) {
+	switch zone := epsg % 100; {
+	case epsg-zone == EPSGWGS84UTMNorth && zone >= 1 && zone <= 60:
+		return Projection{EPSG: epsg, zone: zone, flattening: 1 / 298.257223563}, nil
+	case epsg-zone == EPSGWGS84UTMSouth && zone >= 1 && zone <= 60:
+		return Projection{EPSG: epsg, zone: zone, south: true, flattening: 1 / 298.257223563}, nil
+	case epsg-zone == EPSGETRS89UTMNorth && zone >= 28 && zone <= 38:
+		return Projection{EPSG: epsg, zone: zone, flattening: 1 / 298.257222101}, nil // GRS80
+	}
+
+	return Projection{}, fmt.Errorf("EPSG:%d isn't supported, only UTM zones are (EPSG:326xx, EPSG:327xx and EPSG:258xx)", epsg)
+}
+
+// DetectProjection picks the WGS84 UTM zone of the centre of the bounds of the collections
+func DetectProjection(featureCollections ...*geojson.FeatureCollection) Projection {
+	var bound orb.Bound
+	empty := true
+
+	for _, featureCollection := range featureCollections {
+		if featureCollection == nil {
+			continue
+		}
+
+		for _, f := range featureCollection.Features {
+			if f.Geometry == nil {
+				continue
+			}
+
+			if empty {
+				bound, empty = f.Geometry.Bound(), false
+			} else {
+				bound = bound.Union(f.Geometry.Bound())
+			}
+		}
+	}
+
+	center := bound.Center()
+	zone := min(max(int(math.Floor((center.Lon()+180)/6))+1, 1), 60)
+
+	epsg := EPSGWGS84UTMNorth + zone
+	if center.Lat() < 0 {
+		epsg = EPSGWGS84UTMSouth + zone
+	}
+
+	projection, _ := NewProjection(epsg)
+	return projection
+}
+
+// Project maps a WGS84 point onto the plane with the Krüger series of the transverse Mercator projection,
+// which holds to a millimetre within the zone.
+// Ref: Karney. Transverse Mercator with an accuracy of a few nanometers (2011)
+func (p Projection) Project(point orb.Point) orb.Point {
+	const (
+		semiMajorAxis = 6378137
+		scale         = 0.9996
+		falseEasting  = 500000
+	)
+
+	n := p.flattening / (2 - p.flattening)
+	rectifying := semiMajorAxis / (1 + n) * (1 + n*n/4 + n*n*n*n/64)
+	alpha := [3]float64{
+		n/2 - 2*n*n/3 + 5*n*n*n/16,
+		13*n*n/48 - 3*n*n*n/5,
+		61 * n * n * n / 240,
+	}
+
+	phi := point.Lat() * math.Pi / 180
+	lambda := (point.Lon() - float64(p.zone*6-183)) * math.Pi / 180
+
+	e := 2 * math.Sqrt(n) / (1 + n)
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - e*math.Atanh(e*math.Sin(phi)))
+	xi := math.Atan2(t, math.Cos(lambda))
+	eta := math.Atanh(math.Sin(lambda) / math.Sqrt(1+t*t))
+
+	easting, northing := eta, xi
+	for j, a := range alpha {
+		k := float64(2 * (j + 1))
+		easting += a * math.Cos(k*xi) * math.Sinh(k*eta)
+		northing += a * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+
+	easting = falseEasting + scale*rectifying*easting
+	northing = scale * rectifying * northing
+	if p.south {
+		northing += 10000000
+	}
+
+	return orb.Point{easting, northing}
+}
+
+// ProjectionFromContext returns the projection the design rules measure areas in, the one of the engine if it has got one.
+// Otherwise, e.g. when a rule is called on its own, it's the UTM zone of the centre of the collections.
+func ProjectionFromContext(ctx context.Context, featureCollections ...*geojson.FeatureCollection) Projection {
+	if projection, ok := ctx.Value(projectionKey{}).(Projection); ok {
+		return projection
+	}
+
+	return DetectProjection(featureCollections...)
+}
+
+// Area measures the area of a geometry in the plane of the projection, in square metres
+func (p Projection) Area(geometry orb.Geometry) float64 {
+	return planar.Area(p.Geometry(geometry))
+}
+
+// Geometry projects a copy of the geometry
+func (p Projection) Geometry(geometry orb.Geometry) orb.Geometry {
+	return project.Geometry(orb.Clone(geometry), p.Project)
+}
+
+// String names the projection, e.g. EPSG:32632
+func (p Projection) String() string {
+	return fmt.Sprintf("EPSG:%d", p.EPSG)
+}
+
+// Measure computes the area and perimeter of every feature of the collection in the plane of the projection
+func (p Projection) Measure(featureCollection *geojson.FeatureCollection) []Measurement {
+	measurements := make([]Measurement, 0, len(featureCollection.Features))
+
+	for i, f := range featureCollection.Features {
+		measurements = append(measurements, p.MeasureFeature(i, f))
+	}
+
+	return measurements
+}
+
+// MeasureFeature computes the area and perimeter of a (Multi)Polygon feature, other geometries measure zero
+func (p Projection) MeasureFeature(index int, feature *geojson.Feature) Measurement {
+	measurement := Measurement{Index: index, ID: feature.ID}
+
+	if polygons, ok := featurePolygons(feature.Geometry); ok {
+		projected := p.Geometry(polygons)
+		measurement.Area = planar.Area(projected)
+		measurement.Perimeter = planar.Length(projected)
+	}
+
+	return measurement
+}
+
+// MARK: Private API
+
+type projectionKey struct{}
+
+// contextWithProjection hands the projection of a run over to its rules
+func contextWithProjection(ctx context.Context, projection Projection) context.Context {
+	return context.WithValue(ctx, projectionKey{}, projection)
+}
@@ -0,0 +1,191 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// FixKind names a kind of fix applied by Repair
+type FixKind string
+
+const (
+	FixClosedRings     FixKind = "closed_rings"
+	FixDuplicatePoints FixKind = "removed_duplicate_points"
+	FixSpikes          FixKind = "removed_spikes"
+	FixRewoundRings    FixKind = "rewound_rings"
+)
+
+// Fix describes the repairs of a single kind applied to a feature
+type Fix struct {
+	Kind     FixKind
+	Feature  FeatureRef
+	Geometry orb.Geometry // the points added or removed, if any
+	Message  string
+}
+
+func (fix Fix) MarshalJSON() ([]byte, error) {
+	doc := struct {
+		Kind     FixKind           `json:"kind"`
+		Message  string            `json:"message"`
+		Feature  FeatureRef        `json:"feature"`
+		Geometry *geojson.Geometry `json:"geometry,omitempty"`
+	}{
+		Kind:    fix.Kind,
+		Message: fix.Message,
+		Feature: fix.Feature,
+	}
+
+	if fix.Geometry != nil {
+		doc.Geometry = geojson.NewGeometry(fix.Geometry)
+	}
+
+	return json.Marshal(doc)
+}
+
+// Repair fixes, in place, the mistakes digitising tools commonly leave behind in the (Multi)Polygons of the collection:
+// it closes open rings, removes consecutive duplicate points and zero-area spikes, and rewinds rings to RFC 7946.
+// It returns the fixes applied, by feature and then by kind. Anything else, e.g. a ring of fewer than 3 distinct points,
+// is left to the design rules.
+func Repair(layer Layer, featureCollection *geojson.FeatureCollection) (applied []Fix) {
+	for i, f := range featureCollection.Features {
+		polygons, ok := featurePolygons(f.Geometry)
+		if !ok {
+			continue
+		}
+
+		var fixes ringFixes
+		for _, p := range polygons {
+			for j := range p {
+				p[j] = fixes.repair(p[j])
+
+				if ringMiswound(p[j], j) {
+					p[j].Reverse()
+					fixes.rewound++
+				}
+			}
+		}
+
+		feature := FeatureRef{Layer: layer, Index: i, ID: f.ID}
+		applied = append(applied, fixes.report(feature)...)
+	}
+
+	return applied
+}
+
+// MARK: Private API
+
+// ringFixes tallies the fixes applied to the rings of a feature
+type ringFixes struct {
+	closed     orb.MultiPoint
+	duplicates orb.MultiPoint
+	spikes     orb.MultiPoint
+	rewound    int
+}
+
+// repair closes the ring and drops its consecutive duplicate points and spikes, the ring is treated as cyclic.
+// Rings of fewer than 3 distinct points, or which collapse to a line, enclose no area there is to repair:
+// those are returned as they are and left to the design rules.
+func (fixes *ringFixes) repair(ring orb.Ring) orb.Ring {
+	if len(uniquePoints(ring)) < 3 {
+		return ring
+	}
+
+	// The fixes only count once the ring turns out to be repairable
+	var tally ringFixes
+
+	points := append([]orb.Point{}, ring...)
+	if ring.Closed() {
+		points = points[:len(points)-1]
+	} else {
+		tally.closed = append(tally.closed, ring[0])
+	}
+
+	// Every removal may turn the neighbours into a duplicate or a spike, so sweep until nothing changes
+	for changed := true; changed && len(points) > 1; {
+		changed = false
+
+		for k := 0; k < len(points) && len(points) > 1; k++ {
+			prev, point, next := points[(k+len(points)-1)%len(points)], points[k], points[(k+1)%len(points)]
+
+			switch {
+			case point.Equal(next):
+				tally.duplicates = append(tally.duplicates, point)
+			case len(points) > 2 && pointSpike(prev, point, next):
+				tally.spikes = append(tally.spikes, point)
+			default:
+				continue
+			}
+
+			points = append(points[:k], points[k+1:]...)
+			changed = true
+			k--
+		}
+	}
+
+	if len(points) < 3 {
+		return ring
+	}
+
+	fixes.closed = append(fixes.closed, tally.closed...)
+	fixes.duplicates = append(fixes.duplicates, tally.duplicates...)
+	fixes.spikes = append(fixes.spikes, tally.spikes...)
+
+	return append(orb.Ring(points), points[0])
+}
+
+// report turns the tally into one repair per kind
+func (fixes *ringFixes) report(feature FeatureRef) (applied []Fix) {
+	if len(fixes.closed) != 0 {
+		applied = append(applied, Fix{
+			Kind:     FixClosedRings,
+			Feature:  feature,
+			Geometry: fixes.closed,
+			Message:  fmt.Sprintf("feature %d: closed %d ring(s)", feature.Index, len(fixes.closed)),
+		})
+	}
+
+	if len(fixes.duplicates) != 0 {
+		applied = append(applied, Fix{
+			Kind:     FixDuplicatePoints,
+			Feature:  feature,
+			Geometry: fixes.duplicates,
+			Message:  fmt.Sprintf("feature %d: removed %d consecutive duplicate point(s)", feature.Index, len(fixes.duplicates)),
+		})
+	}
+
+	if len(fixes.spikes) != 0 {
+		applied = append(applied, Fix{
+			Kind:     FixSpikes,
+			Feature:  feature,
+			Geometry: fixes.spikes,
+			Message:  fmt.Sprintf("feature %d: removed %d zero-area spike(s)", feature.Index, len(fixes.spikes)),
+		})
+	}
+
+	if fixes.rewound != 0 {
+		applied = append(applied, Fix{
+			Kind:    FixRewoundRings,
+			Feature: feature,
+			Message: fmt.Sprintf("feature %d: rewound %d ring(s) to RFC 7946", feature.Index, fixes.rewound),
+		})
+	}
+
+	return applied
+}
+
+// pointSpike tells whether the ring runs out to point and straight back, enclosing no area at all
+func pointSpike(prev, point, next orb.Point) bool {
+	out := orb.Point{point.X() - prev.X(), point.Y() - prev.Y()}
+	back := orb.Point{next.X() - point.X(), next.Y() - point.Y()}
+
+	return out.X()*back.Y()-out.Y()*back.X() == 0 && out.X()*back.X()+out.Y()*back.Y() < 0
+}
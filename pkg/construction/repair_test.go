@@ -0,0 +1,80 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		ring  orb.Ring
+		want  orb.Ring
+		kinds []FixKind
+	}{
+		{
+			name:  "open ring is closed",
+			ring:  orb.Ring{{0, 0}, {1, 0}, {1, 1}},
+			want:  orb.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+			kinds: []FixKind{FixClosedRings},
+		},
+		{
+			name:  "duplicate points and spikes are removed",
+			ring:  orb.Ring{{0, 0}, {1, 0}, {1, 0}, {2, 0}, {1, 0}, {1, 1}, {0, 0}},
+			want:  orb.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+			kinds: []FixKind{FixDuplicatePoints, FixSpikes},
+		},
+		{
+			name:  "clockwise ring is rewound",
+			ring:  orb.Ring{{0, 0}, {1, 1}, {1, 0}, {0, 0}},
+			want:  orb.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+			kinds: []FixKind{FixRewoundRings},
+		},
+		{
+			name: "single point is left alone",
+			ring: orb.Ring{{0, 0}},
+			want: orb.Ring{{0, 0}},
+		},
+		{
+			name: "two distinct points are left alone",
+			ring: orb.Ring{{0, 0}, {1, 0}, {0, 0}},
+			want: orb.Ring{{0, 0}, {1, 0}, {0, 0}},
+		},
+		{
+			name: "ring collapsing to a line is left alone",
+			ring: orb.Ring{{0, 0}, {1, 0}, {2, 0}, {0, 0}},
+			want: orb.Ring{{0, 0}, {1, 0}, {2, 0}, {0, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			featureCollection := geojson.NewFeatureCollection()
+			featureCollection.Append(geojson.NewFeature(orb.Polygon{tt.ring}))
+
+			fixes := Repair(LayerBuildingLimits, featureCollection)
+
+			if got := featureCollection.Features[0].Geometry.(orb.Polygon)[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ring: got %v, want %v", got, tt.want)
+			}
+
+			var kinds []FixKind
+			for _, fix := range fixes {
+				kinds = append(kinds, fix.Kind)
+			}
+
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("fixes: got %v, want %v", kinds, tt.kinds)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	// ParameterMinLongitude and friends bound the coordinates of every vertex, in degrees
+	ParameterMinLongitude = "min_longitude"
+	ParameterMaxLongitude = "max_longitude"
+	ParameterMinLatitude  = "min_latitude"
+	ParameterMaxLatitude  = "max_latitude"
+)
+
+func init() {
+	// RFC 7946, section 3.1.6: exterior rings are counter-clockwise, holes are clockwise
+	designRuleRegisterCollection(DesignRuleViolationWindingOrder, SeverityError, nil, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		for i, f := range featureCollection.Features {
+			polygons, ok := featurePolygons(f.Geometry)
+
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+			if !ok {
+				continue
+			}
+
+			rings := orb.MultiLineString{}
+			for _, p := range polygons {
+				for j, ring := range p {
+					if ringMiswound(ring, j) {
+						rings = append(rings, orb.LineString(ring))
+					}
+				}
+			}
+
+			if len(rings) != 0 {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: rings,
+					Message:  fmt.Sprintf("feature %d has %d ring(s) wound against RFC 7946, exterior rings must be counter-clockwise and holes clockwise", i, len(rings)),
+				})
+			}
+		}
+
+		return
+	})
+
+	// Projects narrow the bounds down to their site, which catches swapped longitudes and latitudes
+	designRuleRegisterCollection(DesignRuleViolationCoordinateRange, SeverityError, Parameters{
+		ParameterMinLongitude: -180,
+		ParameterMaxLongitude: 180,
+		ParameterMinLatitude:  -90,
+		ParameterMaxLatitude:  90,
+	}, func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+		bound := orb.Bound{
+			Min: orb.Point{params[ParameterMinLongitude], params[ParameterMinLatitude]},
+			Max: orb.Point{params[ParameterMaxLongitude], params[ParameterMaxLatitude]},
+		}
+
+		for i, f := range featureCollection.Features {
+			polygons, ok := featurePolygons(f.Geometry)
+
+			// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+			if !ok {
+				continue
+			}
+
+			outside := []orb.Point{}
+			for _, p := range polygons {
+				for _, ring := range p {
+					for _, point := range ring {
+						if !bound.Contains(point) {
+							outside = append(outside, point)
+						}
+					}
+				}
+			}
+
+			if outside = uniquePoints(outside); len(outside) != 0 {
+				violations = append(violations, Violation{
+					Features: []FeatureRef{{Index: i}},
+					Geometry: orb.MultiPoint(outside),
+					Message:  fmt.Sprintf("feature %d has %d coordinate(s) outside of longitudes [%g, %g] and latitudes [%g, %g], e.g. %v", i, len(outside), bound.Min.X(), bound.Max.X(), bound.Min.Y(), bound.Max.Y(), outside[0]),
+				})
+			}
+		}
+
+		return
+	})
+}
+
+// Rewind reverses, in place, every ring of the collection wound against RFC 7946, so that exterior rings
+// are counter-clockwise and holes clockwise. It returns the number of rings reversed.
+func Rewind(featureCollection *geojson.FeatureCollection) (rewound int) {
+	for _, f := range featureCollection.Features {
+		polygons, ok := featurePolygons(f.Geometry)
+		if !ok {
+			continue
+		}
+
+		for _, p := range polygons {
+			for j, ring := range p {
+				if ringMiswound(ring, j) {
+					ring.Reverse()
+					rewound++
+				}
+			}
+		}
+	}
+
+	return rewound
+}
+
+// MARK: Private API
+
+// ringMiswound tells whether the j-th ring of a polygon is wound the wrong way.
+// Degenerate rings have no orientation at all and are left to the other rules.
+func ringMiswound(ring orb.Ring, j int) bool {
+	orientation := ring.Orientation()
+	if orientation == 0 {
+		return false
+	}
+
+	if j == 0 {
+		return orientation != orb.CCW
+	}
+
+	return orientation != orb.CW
+}
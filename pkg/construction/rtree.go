@@ -0,0 +1,113 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+)
+
+// Maximum number of children per node
+const rtreeNodeCapacity = 16
+
+// rtree is a static R-tree over bounds, packed with the Sort-Tile-Recursive algorithm.
+// It answers which bounds intersect a query bound without comparing every pair.
+// Ref: Leutenegger, Lopez, Edgington. STR: A Simple and Efficient Algorithm for R-Tree Packing (1997)
+type rtree struct {
+	// levels[0] holds the entries, every level above groups consecutive nodes of the level below
+	levels [][]rtreeNode
+}
+
+// rtreeNode covers the nodes [first, last) of the level below. An entry covers the item first.
+type rtreeNode struct {
+	bound       orb.Bound
+	first, last int
+}
+
+func newRTree(bounds []orb.Bound) *rtree {
+	level := make([]rtreeNode, len(bounds))
+	for i, bound := range bounds {
+		level[i] = rtreeNode{bound: bound, first: i, last: i + 1}
+	}
+
+	tree := &rtree{}
+
+	for {
+		strSort(level)
+		tree.levels = append(tree.levels, level)
+
+		if len(level) <= 1 {
+			return tree
+		}
+
+		level = strGroup(level)
+	}
+}
+
+// search returns the items whose bounds intersect bound (touching included), in ascending order
+func (tree *rtree) search(bound orb.Bound) (items []int) {
+	top := len(tree.levels) - 1
+	tree.searchLevel(top, 0, len(tree.levels[top]), bound, &items)
+
+	sort.Ints(items)
+	return items
+}
+
+func (tree *rtree) searchLevel(level, first, last int, bound orb.Bound, items *[]int) {
+	for _, node := range tree.levels[level][first:last] {
+		if !node.bound.Intersects(bound) {
+			continue
+		}
+
+		if level == 0 {
+			*items = append(*items, node.first)
+			continue
+		}
+
+		tree.searchLevel(level-1, node.first, node.last, bound, items)
+	}
+}
+
+// MARK: Private API
+
+// strSort orders the nodes into vertical slices by x and each slice by y, so that
+// consecutive runs of rtreeNodeCapacity nodes are close to each other
+func strSort(nodes []rtreeNode) {
+	pages := int(math.Ceil(float64(len(nodes)) / rtreeNodeCapacity))
+	slices := int(math.Ceil(math.Sqrt(float64(pages))))
+	sliceSize := slices * rtreeNodeCapacity
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].bound.Center()[0] < nodes[j].bound.Center()[0]
+	})
+
+	for start := 0; start < len(nodes); start += sliceSize {
+		slice := nodes[start:min(start+sliceSize, len(nodes))]
+
+		sort.SliceStable(slice, func(i, j int) bool {
+			return slice[i].bound.Center()[1] < slice[j].bound.Center()[1]
+		})
+	}
+}
+
+// strGroup packs consecutive runs of nodes into their parents
+func strGroup(nodes []rtreeNode) (parents []rtreeNode) {
+	for first := 0; first < len(nodes); first += rtreeNodeCapacity {
+		last := min(first+rtreeNodeCapacity, len(nodes))
+
+		bound := nodes[first].bound
+		for _, node := range nodes[first+1 : last] {
+			bound = bound.Union(node.bound)
+		}
+
+		parents = append(parents, rtreeNode{bound: bound, first: first, last: last})
+	}
+
+	return
+}
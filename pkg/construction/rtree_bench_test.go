@@ -0,0 +1,157 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// City blocks of n×n height plateaux on top of building limits of blocks×blocks plateaux each
+var benchmarkSizes = []int{16, 32, 64}
+
+const (
+	benchmarkCell  = 1e-4 // roughly 10 m
+	benchmarkBlock = 4
+)
+
+// MARK: Candidate pairs
+
+func BenchmarkCandidatePairs(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		polygons, _, tree := polygonFeatures(syntheticGrid(n, 1))
+
+		bounds := make([]orb.Bound, len(polygons))
+		for i, p := range polygons {
+			bounds[i] = p.Bound()
+		}
+
+		if naive, indexed := naiveCandidatePairs(bounds), indexedCandidatePairs(bounds, tree); naive != indexed {
+			b.Fatalf("R-tree found %d candidate pairs instead of %d", indexed, naive)
+		}
+
+		b.Run(fmt.Sprintf("naive/features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveCandidatePairs(bounds)
+			}
+		})
+
+		b.Run(fmt.Sprintf("rtree/features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				indexedCandidatePairs(bounds, newRTree(bounds))
+			}
+		})
+	}
+}
+
+// MARK: Design rules
+
+func BenchmarkDesignRuleOverlapped(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		featureCollection := syntheticGrid(n, 1)
+
+		b.Run(fmt.Sprintf("features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if violations := builtinRules[DesignRuleViolationOverlapped].(CollectionRule).CheckCollection(context.Background(), Parameters{}, LayerBuildingLimits, featureCollection); len(violations) != 0 {
+					b.Fatalf("expected no overlaps, got %d", len(violations))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDesignRuleOutOfBound(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		featureCollectionL := syntheticGrid(n/benchmarkBlock, benchmarkBlock)
+		featureCollectionP := syntheticGrid(n, 1)
+
+		// The union of all limits followed by a difference per plateau, as it used to be
+		b.Run(fmt.Sprintf("naive/features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				limits := orb.MultiPolygon{}
+				for _, f := range featureCollectionL.Features {
+					limits = polygonUnion(limits, orb.MultiPolygon{f.Geometry.(orb.Polygon)})
+				}
+
+				for _, f := range featureCollectionP.Features {
+					polygonDifference(orb.MultiPolygon{f.Geometry.(orb.Polygon)}, limits)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("rtree/features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if violations := builtinRules[DesignRuleViolationOutOfBound].(SplitRule).CheckSplits(context.Background(), Parameters{}, featureCollectionL, featureCollectionP); len(violations) != 0 {
+					b.Fatalf("expected no violations, got %d", len(violations))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSplit(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		featureCollectionL := syntheticGrid(n/benchmarkBlock, benchmarkBlock)
+		featureCollectionP := syntheticGrid(n, 1)
+
+		b.Run(fmt.Sprintf("features=%d", n*n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if splits := Split(featureCollectionL, featureCollectionP); len(splits.Features) != n*n {
+					b.Fatalf("expected %d pieces, got %d", n*n, len(splits.Features))
+				}
+			}
+		})
+	}
+}
+
+// MARK: Helpers
+
+// syntheticGrid tiles n×n adjacent squares of size cells each, sharing their edges
+func syntheticGrid(n int, size int) *geojson.FeatureCollection {
+	featureCollection := geojson.NewFeatureCollection()
+	side := float64(size) * benchmarkCell
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			x, y := 10+float64(i)*side, 59+float64(j)*side
+
+			featureCollection.Append(geojson.NewFeature(orb.Polygon{{
+				{x, y}, {x + side, y}, {x + side, y + side}, {x, y + side}, {x, y},
+			}}))
+		}
+	}
+
+	return featureCollection
+}
+
+func naiveCandidatePairs(bounds []orb.Bound) (pairs int) {
+	for i := range bounds {
+		for j := i + 1; j < len(bounds); j++ {
+			if bounds[i].Intersects(bounds[j]) {
+				pairs++
+			}
+		}
+	}
+
+	return
+}
+
+func indexedCandidatePairs(bounds []orb.Bound, tree *rtree) (pairs int) {
+	for i := range bounds {
+		for _, j := range tree.search(bounds[i]) {
+			if j > i {
+				pairs++
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,122 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	// ParameterAreaTolerance is the area (square metres) below which an offence is ignored, see WithProjection.
+	// It used to be "area_tolerance" in square degrees, stored settings of that name are skipped rather than misread.
+	ParameterAreaTolerance = "area_tolerance_m2"
+
+	// ParameterMinThinness is the thinness ratio (4πA/P², 1 for a circle) below which a polygon is a sliver
+	ParameterMinThinness = "min_thinness"
+)
+
+// Severity tells whether a violation rejects a write (error) or is merely reported along with it (warning)
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule is a design rule. Its name is reported as the reason of every violation it finds.
+// A rule is either a CollectionRule or a SplitRule.
+type Rule interface {
+	Name() string
+
+	// Severity is the default severity of the violations found by the rule
+	Severity() Severity
+
+	// Parameters lists every tunable the rule understands along with its default
+	Parameters() Parameters
+}
+
+// CollectionRule checks the features of a single layer
+type CollectionRule interface {
+	Rule
+	CheckCollection(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) []Violation
+}
+
+// SplitRule checks the height plateaux against the building limits
+type SplitRule interface {
+	Rule
+	CheckSplits(ctx context.Context, params Parameters, featureCollectionL, featureCollectionP *geojson.FeatureCollection) []Violation
+}
+
+// Parameters are the tunables of a rule, e.g. {"area_tolerance_m2": 0}
+type Parameters map[string]float64
+
+// RuleSettings enables or disables a rule and overrides its severity and some of its parameters
+type RuleSettings struct {
+	Enabled    *bool      `json:"enabled,omitempty"`
+	Severity   Severity   `json:"severity,omitempty"`
+	Parameters Parameters `json:"parameters,omitempty"`
+}
+
+// RuleSet holds rule settings by rule name. Rules missing from the set keep their defaults.
+type RuleSet map[string]RuleSettings
+
+// NewCollectionRule turns a function into a CollectionRule
+func NewCollectionRule(name string, severity Severity, defaults Parameters, check DesignRuleFuncOne) CollectionRule {
+	return &collectionRule{name: name, severity: severity, defaults: defaults, check: check}
+}
+
+// NewSplitRule turns a function into a SplitRule
+func NewSplitRule(name string, severity Severity, defaults Parameters, check DesignRuleFuncMany) SplitRule {
+	return &splitRule{name: name, severity: severity, defaults: defaults, check: check}
+}
+
+// MARK: Private API
+
+type collectionRule struct {
+	name     string
+	severity Severity
+	defaults Parameters
+	check    DesignRuleFuncOne
+}
+
+func (r *collectionRule) Name() string           { return r.name }
+func (r *collectionRule) Severity() Severity     { return r.severity }
+func (r *collectionRule) Parameters() Parameters { return r.defaults.clone() }
+
+func (r *collectionRule) CheckCollection(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) []Violation {
+	return r.check(ctx, params, layer, featureCollection)
+}
+
+type splitRule struct {
+	name     string
+	severity Severity
+	defaults Parameters
+	check    DesignRuleFuncMany
+}
+
+func (r *splitRule) Name() string           { return r.name }
+func (r *splitRule) Severity() Severity     { return r.severity }
+func (r *splitRule) Parameters() Parameters { return r.defaults.clone() }
+
+func (r *splitRule) CheckSplits(ctx context.Context, params Parameters, featureCollectionL, featureCollectionP *geojson.FeatureCollection) []Violation {
+	return r.check(ctx, params, featureCollectionL, featureCollectionP)
+}
+
+func (s Severity) valid() bool {
+	return s == SeverityError || s == SeverityWarning
+}
+
+func (p Parameters) clone() Parameters {
+	clone := make(Parameters, len(p))
+	for name, value := range p {
+		clone[name] = value
+	}
+
+	return clone
+}
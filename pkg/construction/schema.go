@@ -0,0 +1,147 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// PropertyType is the JSON type of a feature property
+type PropertyType string
+
+const (
+	PropertyTypeNumber  PropertyType = "number"
+	PropertyTypeString  PropertyType = "string"
+	PropertyTypeBoolean PropertyType = "boolean"
+)
+
+// PropertySchema constrains a single feature property. Numbers must be finite and within [Minimum, Maximum].
+type PropertySchema struct {
+	Type     PropertyType `json:"type"`
+	Required bool         `json:"required,omitempty"`
+	Minimum  *float64     `json:"minimum,omitempty"`
+	Maximum  *float64     `json:"maximum,omitempty"`
+}
+
+// Schema constrains the feature properties of every layer by property name.
+// Properties the schema doesn't mention are left alone.
+type Schema map[Layer]map[string]PropertySchema
+
+// DefaultSchema requires a finite numeric elevation on every height plateau
+func DefaultSchema() Schema {
+	return Schema{
+		LayerHeightPlateaux: {
+			PropertyElevation: {Type: PropertyTypeNumber, Required: true},
+		},
+	}
+}
+
+// WithSchema checks feature properties against schema instead of DefaultSchema
+func WithSchema(schema Schema) DesignRuleEngineOption {
+	return func(dre *DesignRuleEngine) {
+		dre.byName[DesignRuleViolationPropertySchema.String()].Rule = newSchemaRule(schema)
+	}
+}
+
+func init() {
+	builtinRules[DesignRuleViolationPropertySchema] = newSchemaRule(DefaultSchema())
+}
+
+// MARK: Private API
+
+func newSchemaRule(schema Schema) CollectionRule {
+	return NewCollectionRule(DesignRuleViolationPropertySchema.String(), SeverityError, nil,
+		func(ctx context.Context, params Parameters, layer Layer, featureCollection *geojson.FeatureCollection) (violations []Violation) {
+			properties := schema[layer]
+
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for i, f := range featureCollection.Features {
+				for _, name := range names {
+					if problem := properties[name].check(f.Properties, name); problem != "" {
+						violations = append(violations, Violation{
+							Features: []FeatureRef{{Index: i}},
+							Message:  fmt.Sprintf("feature %d: property %s %s", i, name, problem),
+						})
+					}
+				}
+			}
+
+			return
+		})
+}
+
+// check returns what's wrong with the property, if anything
+func (s PropertySchema) check(properties geojson.Properties, name string) (problem string) {
+	value, ok := properties[name]
+	if !ok || value == nil {
+		if s.Required {
+			return "is required"
+		}
+
+		return ""
+	}
+
+	switch s.Type {
+	case PropertyTypeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return fmt.Sprintf("must be a number, not %s", jsonType(value))
+		}
+
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return "must be finite"
+		}
+
+		if s.Minimum != nil && number < *s.Minimum {
+			return fmt.Sprintf("must be at least %g", *s.Minimum)
+		}
+
+		if s.Maximum != nil && number > *s.Maximum {
+			return fmt.Sprintf("must be at most %g", *s.Maximum)
+		}
+
+	case PropertyTypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Sprintf("must be a string, not %s", jsonType(value))
+		}
+
+	case PropertyTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("must be a boolean, not %s", jsonType(value))
+		}
+	}
+
+	return ""
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value any) string {
+	switch value.(type) {
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("a %T", value)
+	}
+}
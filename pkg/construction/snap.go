@@ -0,0 +1,209 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"errors"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// Precision is the model vertices are snapped to before the design rules run, in coordinate units.
+// CAD drawings share edges which differ by a hair, snapping turns those micro-overlaps and micro-gaps into shared edges.
+// The zero value leaves the geometries alone.
+type Precision struct {
+	// Grid rounds every coordinate to a multiple of it, e.g. 1e-9
+	Grid float64 `json:"grid,omitempty"`
+
+	// Tolerance moves a vertex onto the nearest vertex of another feature within that distance
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// Validate makes sure the grid and the tolerance are finite and not negative
+func (precision Precision) Validate() error {
+	var errs []error
+
+	if math.IsNaN(precision.Grid) || math.IsInf(precision.Grid, 0) || precision.Grid < 0 {
+		errs = append(errs, errors.New("grid must be a finite non-negative number"))
+	}
+
+	if math.IsNaN(precision.Tolerance) || math.IsInf(precision.Tolerance, 0) || precision.Tolerance < 0 {
+		errs = append(errs, errors.New("tolerance must be a finite non-negative number"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Snap moves, in place, the vertices of the (Multi)Polygons of the collection onto the grid and then onto
+// nearby vertices of other features, be it of the collection or of the references. The references are
+// left alone, they are expected to be snapped already, e.g. the stored complementary layer.
+// Consecutive vertices which end up on the same spot are merged. It returns the number of vertices moved.
+func (precision Precision) Snap(featureCollection *geojson.FeatureCollection, references ...*geojson.FeatureCollection) (snapped int) {
+	vertices := collectVertices(featureCollection)
+	if len(vertices) == 0 {
+		return 0
+	}
+
+	targets := make(map[orb.Point]orb.Point, len(vertices))
+
+	if precision.Grid > 0 {
+		for _, v := range vertices {
+			targets[v.point] = orb.Point{gridRound(v.point.X(), precision.Grid), gridRound(v.point.Y(), precision.Grid)}
+		}
+	}
+
+	if precision.Tolerance > 0 {
+		precision.snapToNeighbours(vertices, targets, references)
+	}
+
+	for _, f := range featureCollection.Features {
+		polygons, ok := featurePolygons(f.Geometry)
+		if !ok {
+			continue
+		}
+
+		for _, p := range polygons {
+			for j, ring := range p {
+				for k, point := range ring {
+					if target, ok := targets[point]; ok && target != point {
+						ring[k] = target
+						snapped++
+					}
+				}
+
+				p[j] = mergeDuplicatePoints(ring)
+			}
+		}
+	}
+
+	return snapped
+}
+
+// MARK: Private API
+
+// snapVertex is a vertex of a feature, the owner tells apart the features of the collection (>= 0) from those of the references
+type snapVertex struct {
+	point orb.Point
+	owner int
+}
+
+// collectVertices lists the vertices of the (Multi)Polygons of the collection, in order
+func collectVertices(featureCollection *geojson.FeatureCollection) (vertices []snapVertex) {
+	if featureCollection == nil {
+		return nil
+	}
+
+	for i, f := range featureCollection.Features {
+		polygons, ok := featurePolygons(f.Geometry)
+		if !ok {
+			continue
+		}
+
+		for _, p := range polygons {
+			for _, ring := range p {
+				for _, point := range ring {
+					vertices = append(vertices, snapVertex{point: point, owner: i})
+				}
+			}
+		}
+	}
+
+	return vertices
+}
+
+// snapToNeighbours picks the target of every vertex in order: the nearest vertex of another feature within the tolerance,
+// where the references stay put and the vertices already visited stand at their targets. The grid targets, if any, come first.
+// Vertices sharing a position share the target too, so that rings stay closed and shared edges stay shared.
+func (precision Precision) snapToNeighbours(vertices []snapVertex, targets map[orb.Point]orb.Point, references []*geojson.FeatureCollection) {
+	anchors := []snapVertex{}
+	for r, reference := range references {
+		for _, v := range collectVertices(reference) {
+			anchors = append(anchors, snapVertex{point: v.point, owner: -1 - r})
+		}
+	}
+
+	// The vertices of the collection are anchors too, at their grid targets
+	for _, v := range vertices {
+		if target, ok := targets[v.point]; ok {
+			v.point = target
+		}
+		anchors = append(anchors, v)
+	}
+
+	bounds := make([]orb.Bound, len(anchors))
+	for i, a := range anchors {
+		bounds[i] = a.point.Bound()
+	}
+	tree := newRTree(bounds)
+
+	visited := make(map[orb.Point]bool, len(vertices))
+	offset := len(anchors) - len(vertices)
+
+	for i, v := range vertices {
+		if visited[v.point] {
+			continue
+		}
+		visited[v.point] = true
+
+		position := anchors[offset+i].point
+		target, nearest := position, precision.Tolerance
+
+		// Visited vertices may have moved by the tolerance since the tree was packed
+		for _, candidate := range tree.search(position.Bound().Pad(2 * precision.Tolerance)) {
+			anchor := anchors[candidate]
+
+			// Vertices of the collection only attract once visited, and never those of the same feature
+			if anchor.owner == v.owner || (candidate >= offset && !visited[vertices[candidate-offset].point]) {
+				continue
+			}
+
+			if candidate >= offset {
+				anchor.point = targetOf(targets, vertices[candidate-offset].point)
+			}
+
+			if distance := planar.Distance(position, anchor.point); distance <= nearest && anchor.point != position {
+				target, nearest = anchor.point, distance
+			}
+		}
+
+		targets[v.point] = target
+	}
+}
+
+// gridRound rounds x to a multiple of grid. Grids such as 1e-9 are handled by their inverse, which
+// is a whole number, so that the coordinates come out as short as they look.
+func gridRound(x, grid float64) float64 {
+	if inverse := math.Round(1 / grid); inverse >= 1 && math.Abs(inverse*grid-1) < 1e-12 {
+		return math.Round(x*inverse) / inverse
+	}
+
+	return math.Round(x/grid) * grid
+}
+
+// targetOf returns where a vertex goes, the vertex itself if it stays put
+func targetOf(targets map[orb.Point]orb.Point, point orb.Point) orb.Point {
+	if target, ok := targets[point]; ok {
+		return target
+	}
+
+	return point
+}
+
+// mergeDuplicatePoints drops consecutive duplicates of a ring, keeping it closed if it was
+func mergeDuplicatePoints(ring orb.Ring) orb.Ring {
+	merged := ring[:0]
+	for k, point := range ring {
+		if k == 0 || !point.Equal(merged[len(merged)-1]) {
+			merged = append(merged, point)
+		}
+	}
+
+	return merged
+}
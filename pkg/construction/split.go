@@ -0,0 +1,103 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	PropertyElevation          = "elevation"
+	PropertyBuildingLimitIndex = "building_limit_index"
+	PropertyBuildingLimitID    = "building_limit_id"
+	PropertyHeightPlateauIndex = "height_plateau_index"
+	PropertyHeightPlateauID    = "height_plateau_id"
+)
+
+// Split intersects every building limit with every height plateau and returns
+// one feature per resulting piece. Each piece carries the elevation of its
+// height plateau and references (index and id) to both of its sources.
+// If either source is a MultiPolygon, all pieces of the pair make up a single MultiPolygon feature.
+func Split(featureCollectionL, featureCollectionP *geojson.FeatureCollection) *geojson.FeatureCollection {
+	splits := geojson.NewFeatureCollection()
+	plateaux, indices, tree := polygonFeatures(featureCollectionP)
+
+	for i, fLimit := range featureCollectionL.Features {
+		pLimit, ok := featurePolygons(fLimit.Geometry)
+
+		// Skip all elements other than (Multi)Polygon because those are being taken care off by NotPolygon rule
+		if !ok {
+			continue
+		}
+
+		// Only plateaux with intersecting bounds can share area with the limit.
+		// Pieces are gathered per plateau feature, in order of the plateaux.
+		plateauIndices := []int{}
+		pieces := map[int]orb.MultiPolygon{}
+
+		for _, k := range tree.search(pLimit.Bound()) {
+			j := indices[k]
+			if _, ok := pieces[j]; !ok {
+				plateauIndices = append(plateauIndices, j)
+			}
+
+			pieces[j] = append(pieces[j], polygonIntersection(pLimit, orb.MultiPolygon{plateaux[k]})...)
+		}
+
+		for _, j := range plateauIndices {
+			fPlateau := featureCollectionP.Features[j]
+
+			for _, geometry := range splitGeometries(fLimit.Geometry, fPlateau.Geometry, pieces[j]) {
+				feature := geojson.NewFeature(geometry)
+
+				if elevation, ok := fPlateau.Properties[PropertyElevation]; ok {
+					feature.Properties[PropertyElevation] = elevation
+				}
+
+				feature.Properties[PropertyBuildingLimitIndex] = i
+				feature.Properties[PropertyHeightPlateauIndex] = j
+
+				if fLimit.ID != nil {
+					feature.Properties[PropertyBuildingLimitID] = fLimit.ID
+				}
+
+				if fPlateau.ID != nil {
+					feature.Properties[PropertyHeightPlateauID] = fPlateau.ID
+				}
+
+				splits.Append(feature)
+			}
+		}
+	}
+
+	return splits
+}
+
+// MARK: Private API
+
+// splitGeometries turns the pieces of a limit and a plateau into feature geometries:
+// a Polygon per piece for two Polygons, a single (Multi)Polygon otherwise
+func splitGeometries(limit, plateau orb.Geometry, pieces orb.MultiPolygon) []orb.Geometry {
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	_, limitPolygon := limit.(orb.Polygon)
+	_, plateauPolygon := plateau.(orb.Polygon)
+
+	if !limitPolygon || !plateauPolygon {
+		return []orb.Geometry{singlePolygon(pieces)}
+	}
+
+	geometries := make([]orb.Geometry, 0, len(pieces))
+	for _, piece := range pieces {
+		geometries = append(geometries, piece)
+	}
+
+	return geometries
+}
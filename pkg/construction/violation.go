@@ -0,0 +1,131 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package construction
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Layer names the feature collection a feature belongs to
+type Layer string
+
+const (
+	LayerBuildingLimits Layer = "building_limits"
+	LayerHeightPlateaux Layer = "height_plateaux"
+)
+
+// FeatureRef points at a feature within a layer
+type FeatureRef struct {
+	Layer Layer `json:"layer"`
+	Index int   `json:"index"`
+	ID    any   `json:"id,omitempty"`
+}
+
+// Violation describes a single breach of a design rule: which features are at fault and where
+type Violation struct {
+	Rule     string // name of the rule, e.g. DesignRuleViolationOverlapped
+	Severity Severity
+	Features []FeatureRef
+	Geometry orb.Geometry // the offending part, e.g. the overlap region
+	Message  string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+func (v Violation) MarshalJSON() ([]byte, error) {
+	doc := struct {
+		Reason   string            `json:"reason"`
+		Severity Severity          `json:"severity"`
+		Message  string            `json:"message"`
+		Features []FeatureRef      `json:"features"`
+		Geometry *geojson.Geometry `json:"geometry,omitempty"`
+	}{
+		Reason:   v.Rule,
+		Severity: v.Severity,
+		Message:  v.Message,
+		Features: v.Features,
+	}
+
+	if doc.Features == nil {
+		doc.Features = []FeatureRef{}
+	}
+
+	if v.Geometry != nil {
+		doc.Geometry = geojson.NewGeometry(v.Geometry)
+	}
+
+	return json.Marshal(doc)
+}
+
+// SplitBySeverity separates errors from warnings, keeping their order
+func SplitBySeverity(violations []Violation) (errors, warnings []Violation) {
+	errors, warnings = []Violation{}, []Violation{}
+
+	for _, v := range violations {
+		if v.Severity == SeverityWarning {
+			warnings = append(warnings, v)
+		} else {
+			errors = append(errors, v)
+		}
+	}
+
+	return
+}
+
+// MARK: Private API
+
+// complete fills in the rule, the severity and the layer and id of every feature reference
+func (v *Violation) complete(rule string, severity Severity, layers map[Layer]*geojson.FeatureCollection, defaultLayer Layer) {
+	v.Rule = rule
+	v.Severity = severity
+
+	for i := range v.Features {
+		ref := &v.Features[i]
+
+		if ref.Layer == "" {
+			ref.Layer = defaultLayer
+		}
+
+		if fc, ok := layers[ref.Layer]; ok && ref.Index >= 0 && ref.Index < len(fc.Features) {
+			ref.ID = fc.Features[ref.Index].ID
+		}
+	}
+
+	if v.Message == "" {
+		v.Message = rule
+	}
+}
+
+// sortViolations orders the violations of a rule by the features at fault
+func sortViolations(violations []Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+
+		for k := 0; k < len(a.Features) && k < len(b.Features); k++ {
+			if a.Features[k].Layer != b.Features[k].Layer {
+				return a.Features[k].Layer < b.Features[k].Layer
+			}
+
+			if a.Features[k].Index != b.Features[k].Index {
+				return a.Features[k].Index < b.Features[k].Index
+			}
+		}
+
+		if len(a.Features) != len(b.Features) {
+			return len(a.Features) < len(b.Features)
+		}
+
+		return a.Message < b.Message
+	})
+}
@@ -11,16 +11,23 @@ func _() {
 	_ = x[DesignRuleViolationOverlapped-0]
 	_ = x[DesignRuleViolationNotClosed-1]
 	_ = x[DesignRuleViolationNotPolygon-2]
-	_ = x[DesignRuleViolationOutOfBound-3]
+	_ = x[DesignRuleViolationSelfIntersection-3]
+	_ = x[DesignRuleViolationSliver-4]
+	_ = x[DesignRuleViolationPropertySchema-5]
+	_ = x[DesignRuleViolationWindingOrder-6]
+	_ = x[DesignRuleViolationCoordinateRange-7]
+	_ = x[DesignRuleViolationOutOfBound-8]
+	_ = x[DesignRuleViolationNotCovered-9]
 }
 
-const _DesignRuleViolation_name = "DesignRuleViolationOverlappedDesignRuleViolationNotClosedDesignRuleViolationNotPolygonDesignRuleViolationOutOfBound"
+const _DesignRuleViolation_name = "DesignRuleViolationOverlappedDesignRuleViolationNotClosedDesignRuleViolationNotPolygonDesignRuleViolationSelfIntersectionDesignRuleViolationSliverDesignRuleViolationPropertySchemaDesignRuleViolationWindingOrderDesignRuleViolationCoordinateRangeDesignRuleViolationOutOfBoundDesignRuleViolationNotCovered"
 
-var _DesignRuleViolation_index = [...]uint8{0, 29, 57, 86, 115}
+var _DesignRuleViolation_index = [...]uint16{0, 29, 57, 86, 121, 146, 179, 210, 244, 273, 302}
 
 func (i DesignRuleViolation) String() string {
-	if i < 0 || i >= DesignRuleViolation(len(_DesignRuleViolation_index)-1) {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_DesignRuleViolation_index)-1 {
 		return "DesignRuleViolation(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _DesignRuleViolation_name[_DesignRuleViolation_index[i]:_DesignRuleViolation_index[i+1]]
+	return _DesignRuleViolation_name[_DesignRuleViolation_index[idx]:_DesignRuleViolation_index[idx+1]]
 }
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	ginAPI "github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
@@ -26,116 +27,201 @@ import (
 type ContextKey string
 
 const (
-	ctxKeyLogger           ContextKey = `looger`  // type: logr.Logger
-	ctxKeyGin              ContextKey = `gin`     // type: *gin.Context
-	ctxKeyDesignRuleEngine ContextKey = `dre`     // type: *construction.DesignRuleEngine
-	ctxKeyProject          ContextKey = `project` // type: Project
-	ctxKeyModel            ContextKey = `model`   // type: *mnemosyne.Mnemosyne
+	ctxKeyLogger           ContextKey = `looger`    // type: logr.Logger
+	ctxKeyGin              ContextKey = `gin`       // type: *gin.Context
+	ctxKeyDesignRuleEngine ContextKey = `dre`       // type: *construction.DesignRuleEngine
+	ctxKeyPrecision        ContextKey = `precision` // type: construction.Precision
+	ctxKeyProject          ContextKey = `project`   // type: Project
+	ctxKeyModel            ContextKey = `model`     // type: mnemosyne.Mnemosyne
+)
+
+const (
+	headerAuthor  = "X-Author"
+	defaultAuthor = "anonymous"
+
+	// Upper bound of a single run of the Design Rule Engine
+	validationTimeout = 5 * time.Second
 )
 
 func Register(ginRouter *ginAPI.RouterGroup) {
+	projects := ginRouter.Group("/projects")
 	api := ginRouter.Group("/projects/:project_id")
 
 	// Bind project ID
 	api.Use(projectIDMiddleware)
 
-	// MARK: GET /building_limits
-	api.GET("/building_limits", func(gin *ginAPI.Context) {
-		log := logger.FromContext(gin)
-		project := gin.MustGet("project").(Project)
-		model := gin.MustGet("model").(*mnemosyne.Mnemosyne)
+	// MARK: POST /projects
+	projects.POST("", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "project")
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
 		// Context business logic
 		ctx := context.Background()
 		ctx = context.WithValue(ctx, ctxKeyLogger, log)
 		ctx = context.WithValue(ctx, ctxKeyGin, gin)
 
-		buildingLimits, err := model.GetBuildingLimits(project.ID)
+		var request CreateProjectRequest
+		if err := gin.ShouldBindJSON(&request); err != nil {
+			if processed := handleMallformedJSON(ctx, err); processed {
+				return
+			}
 
-		if notFound, ok := handleNotFound(ctx, err); !ok || notFound {
+			handleBadRequest(ctx, err)
 			return
 		}
 
-		log.V(4).Info("building limits found", "object", buildingLimits)
+		// Metadata is a free-form JSON object
+		if len(request.Metadata) == 0 || string(request.Metadata) == "null" {
+			request.Metadata = json.RawMessage("{}")
+		}
 
-		// Make sure it's a well-formatted GeoJSON Object
-		geoJsonObj, err := geojson.UnmarshalFeatureCollection([]byte(buildingLimits))
+		var metadata map[string]any
+		if err := json.Unmarshal(request.Metadata, &metadata); err != nil {
+			handleBadRequest(ctx, fmt.Errorf("metadata must be a JSON object: %w", err))
+			return
+		}
+
+		project, err := model.CreateProject(request.Name, request.Description, string(request.Metadata))
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		gin.JSON(http.StatusOK, ginAPI.H{"data": *geoJsonObj})
+		log.V(3).Info("project created", "project-id", project.ID)
+
+		gin.JSON(http.StatusCreated, ginAPI.H{"data": NewProjectResource(project)})
 	})
 
-	// MARK: PATCH /building_limits
-	api.PATCH("/building_limits", func(gin *ginAPI.Context) {
-		ctx := makeUpdateContext(gin, "object-name", "building_limits")
-		log := ctx.Value(ctxKeyLogger).(logr.Logger)
-		project := ctx.Value(ctxKeyProject).(Project)
-		model := ctx.Value(ctxKeyModel).(*mnemosyne.Mnemosyne)
-		dre := ctx.Value(ctxKeyDesignRuleEngine).(*construction.DesignRuleEngine)
+	// MARK: GET /projects
+	projects.GET("", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
-		body, err := io.ReadAll(gin.Request.Body)
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		projects, err := model.ListProjects()
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		// Make sure it's a well-formatted GeoJSON Object
-		featureCollectionRequest, err := geojson.UnmarshalFeatureCollection(body)
+		resources := make([]ProjectResource, 0, len(projects))
+		for _, project := range projects {
+			resources = append(resources, NewProjectResource(project))
+		}
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": resources})
+	})
+
+	// MARK: GET /projects/:project_id
+	api.GET("", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
 
+		stored, err := model.GetProject(project.ID)
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		// Validate the collection
-		if ok, violations := dre.ValidateCollection(featureCollectionRequest); !ok {
-			handleDesignRuleViolations(ctx, violations)
-			return
-		}
+		gin.JSON(http.StatusOK, ginAPI.H{"data": NewProjectResource(stored)})
+	})
 
-		// Check design rules for splits
-		// Fetch complementary feature collection
-		complementaryJsonData, err := model.GetHeightPlateaux(project.ID)
-		notFound, ok := handleNotFound(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "height-plateaux")), err)
+	// MARK: DELETE /projects/:project_id
+	api.DELETE("", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
-		if !ok {
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		err := model.DeleteProject(project.ID)
+		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		if !notFound {
-			featureCollectionComplementary, err := geojson.UnmarshalFeatureCollection([]byte(complementaryJsonData))
-			if ok := handleInternalServerError(ctx, err); !ok {
-				return
-			}
+		log.V(3).Info("project deleted")
 
-			// Check design rules
-			if ok, violations := dre.ValidateSplits(featureCollectionRequest, featureCollectionComplementary); !ok {
-				handleDesignRuleViolations(ctx, violations)
-				return
-			}
+		gin.Status(http.StatusNoContent)
+	})
+
+	// MARK: GET /building_limits
+	api.GET("/building_limits", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		buildingLimits, err := model.GetBuildingLimits(project.ID)
+
+		if notFound, ok := handleNotFound(ctx, err); !ok || notFound {
+			return
 		}
 
-		// Update the model for no errors were found
-		geoJson, err := featureCollectionRequest.MarshalJSON()
-		if ok := handleInternalServerError(ctx, err); !ok {
+		log.V(4).Info("building limits found", "object", buildingLimits.Data)
+
+		gin.Header(headerETag, revisionETag(buildingLimits))
+		if notModified(gin, buildingLimits) {
+			gin.Status(http.StatusNotModified)
 			return
 		}
 
-		err = model.UpdateBuildingLimits(project.ID, string(geoJson[:]))
+		// Make sure it's a well-formatted GeoJSON Object
+		geoJsonObj, err := geojson.UnmarshalFeatureCollection([]byte(buildingLimits.Data))
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
+		projection := newProjection(log, gin.MustGet("epsg").(int), geoJsonObj)
+
 		gin.JSON(http.StatusOK, ginAPI.H{
-			"data": *featureCollectionRequest,
+			"data":         *geoJsonObj,
+			"revision":     NewRevisionResource(buildingLimits),
+			"warnings":     revisionWarnings(buildingLimits),
+			"measurements": newMeasurementsResource(projection, projection.Measure(geoJsonObj)),
 		})
 	})
 
+	// MARK: PATCH /building_limits
+	api.PATCH("/building_limits", func(gin *ginAPI.Context) {
+		ctx := makeUpdateContext(gin, "object-name", "building_limits")
+
+		mutate, ok := bindPatch(ctx, construction.LayerBuildingLimits)
+		if !ok {
+			return
+		}
+
+		revision, featureCollection, ok := updateBuildingLimits(ctx, mutate)
+		if !ok {
+			return
+		}
+
+		gin.Header(headerETag, revisionETag(revision))
+		gin.JSON(http.StatusOK, withFixes(gin, ginAPI.H{
+			"data":     *featureCollection,
+			"revision": NewRevisionResource(revision),
+			"warnings": revisionWarnings(revision),
+		}))
+	})
+
 	// MARK: GET /height_plateaus
 	api.GET("/height_plateaus", func(gin *ginAPI.Context) {
 		log := logger.FromContext(gin)
 		project := gin.MustGet("project").(Project)
-		model := gin.MustGet("model").(*mnemosyne.Mnemosyne)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
 		// Context business logic
 		ctx := context.Background()
@@ -148,118 +234,165 @@ func Register(ginRouter *ginAPI.RouterGroup) {
 			return
 		}
 
-		log.V(4).Info("height plateaux found", "object", heightPlateaux)
+		log.V(4).Info("height plateaux found", "object", heightPlateaux.Data)
+
+		gin.Header(headerETag, revisionETag(heightPlateaux))
+		if notModified(gin, heightPlateaux) {
+			gin.Status(http.StatusNotModified)
+			return
+		}
 
 		// Make sure it's a well-formatted GeoJSON Object
-		geoJsonObj, err := geojson.UnmarshalFeatureCollection([]byte(heightPlateaux))
+		geoJsonObj, err := geojson.UnmarshalFeatureCollection([]byte(heightPlateaux.Data))
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		gin.JSON(http.StatusOK, ginAPI.H{"data": *geoJsonObj})
+		projection := newProjection(log, gin.MustGet("epsg").(int), geoJsonObj)
+
+		gin.JSON(http.StatusOK, ginAPI.H{
+			"data":         *geoJsonObj,
+			"revision":     NewRevisionResource(heightPlateaux),
+			"warnings":     revisionWarnings(heightPlateaux),
+			"measurements": newMeasurementsResource(projection, projection.Measure(geoJsonObj)),
+		})
 	})
 
 	// MARK: PATCH /height_plateaus
 	api.PATCH("/height_plateaus", func(gin *ginAPI.Context) {
 		ctx := makeUpdateContext(gin, "object-name", "height plateaus")
 
-		project := ctx.Value(ctxKeyProject).(Project)
-		model := ctx.Value(ctxKeyModel).(*mnemosyne.Mnemosyne)
-		dre := ctx.Value(ctxKeyDesignRuleEngine).(*construction.DesignRuleEngine)
-		log := ctx.Value(ctxKeyLogger).(logr.Logger)
-
-		body, err := io.ReadAll(gin.Request.Body)
-		if ok := handleInternalServerError(ctx, err); !ok {
+		mutate, ok := bindPatch(ctx, construction.LayerHeightPlateaux)
+		if !ok {
 			return
 		}
 
-		// Make sure it's a well-formatted GeoJSON Object
-		featureCollectionRequest, err := geojson.UnmarshalFeatureCollection(body)
-		if ok := handleInternalServerError(ctx, err); !ok {
+		revision, featureCollection, ok := updateHeightPlateaux(ctx, mutate)
+		if !ok {
 			return
 		}
 
-		geoJson, err := featureCollectionRequest.MarshalJSON()
-		if ok := handleInternalServerError(ctx, err); !ok {
-			return
-		}
+		gin.Header(headerETag, revisionETag(revision))
+		gin.JSON(http.StatusOK, withFixes(gin, ginAPI.H{
+			"data":     *featureCollection,
+			"revision": NewRevisionResource(revision),
+			"warnings": revisionWarnings(revision),
+		}))
+	})
 
-		// Check design rules for collection
-		if ok, violations := dre.ValidateCollection(featureCollectionRequest); !ok {
-			handleDesignRuleViolations(ctx, violations)
-			return
-		}
+	// MARK: GET /split_building_limits
+	api.GET("/split_building_limits", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
 
-		// Check design rules for splits
-		// Fetch complementary feature collection
-		complementaryJsonData, err := model.GetBuildingLimits(project.ID)
-		notFound, ok := handleNotFound(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "building-limits")), err)
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
 
-		if !ok {
+		buildingLimits, err := model.GetBuildingLimits(project.ID)
+		if notFound, ok := handleNotFound(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "building-limits")), err); !ok || notFound {
 			return
 		}
 
-		if notFound {
-			gin.JSON(http.StatusUnprocessableEntity, ginAPI.H{
-				"message": "Building limits don't exist",
-				"error": ginAPI.H{
-					"code": http.StatusUnprocessableEntity,
-					"errors": []ginAPI.H{
-						{"reason": fmt.Sprintf("%T", GenericApiError{})},
-					},
-				},
-			})
-
+		heightPlateaux, err := model.GetHeightPlateaux(project.ID)
+		if notFound, ok := handleNotFound(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "height-plateaux")), err); !ok || notFound {
 			return
 		}
 
-		featureCollectionComplementary, err := geojson.UnmarshalFeatureCollection([]byte(complementaryJsonData))
+		// Make sure those are well-formatted GeoJSON Objects
+		featureCollectionL, err := geojson.UnmarshalFeatureCollection([]byte(buildingLimits.Data))
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
-		// Check design rules
-		if ok, violations := dre.ValidateSplits(featureCollectionComplementary, featureCollectionRequest); !ok {
-			handleDesignRuleViolations(ctx, violations)
-			return
-		}
-
-		err = model.UpdateHeightPlateaux(project.ID, string(geoJson[:]))
+		featureCollectionP, err := geojson.UnmarshalFeatureCollection([]byte(heightPlateaux.Data))
 		if ok := handleInternalServerError(ctx, err); !ok {
 			return
 		}
 
+		splitBuildingLimits := construction.Split(featureCollectionL, featureCollectionP)
+
+		log.V(4).Info("split building limits computed", "features", len(splitBuildingLimits.Features))
+
+		projection := newProjection(log, gin.MustGet("epsg").(int), featureCollectionL)
+
 		gin.JSON(http.StatusOK, ginAPI.H{
-			"data": *featureCollectionRequest,
+			"data":         *splitBuildingLimits,
+			"measurements": newMeasurementsResource(projection, projection.Measure(splitBuildingLimits)),
 		})
 	})
 
-	// MARK: GET /height_plateaus
-	api.GET("/split_building_limits", func(gin *ginAPI.Context) {
-		log := logger.FromContext(gin)
-		project := gin.MustGet("project").(Project)
-		model := gin.MustGet("model").(*mnemosyne.Mnemosyne)
+	registerRevisions(api, "/building_limits", mnemosyne.ObjectBuildingLimits, updateBuildingLimits)
+	registerRevisions(api, "/height_plateaus", mnemosyne.ObjectHeightPlateaux, updateHeightPlateaux)
 
-		// Context business logic
-		ctx := context.Background()
-		ctx = context.WithValue(ctx, ctxKeyLogger, log)
-		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+	registerFeatures(api, "/building_limits", mnemosyne.ObjectBuildingLimits, updateBuildingLimits)
+	registerFeatures(api, "/height_plateaus", mnemosyne.ObjectHeightPlateaux, updateHeightPlateaux)
 
-		split_building_limits, err := model.GetHeightPlateaux(project.ID)
+	registerDesignRules(api)
+	registerPrecision(api)
+	registerCRS(api)
 
-		if notFound, ok := handleNotFound(ctx, err); !ok || notFound {
+	// MARK: POST /validate
+	api.POST("/validate", func(gin *ginAPI.Context) {
+		ctx := makeUpdateContext(gin, "object-name", "validation")
+		log := ctx.Value(ctxKeyLogger).(logr.Logger)
+		project := ctx.Value(ctxKeyProject).(Project)
+		model := ctx.Value(ctxKeyModel).(mnemosyne.Mnemosyne)
+
+		precision := ctx.Value(ctxKeyPrecision).(construction.Precision)
+
+		featureCollectionL, featureCollectionP, ok := bindValidateRequest(ctx)
+		if !ok {
 			return
 		}
 
-		log.V(4).Info("split building limits found", "object", split_building_limits)
+		// The collections given are snapped as if they were written, the stored ones are snapped already
+		givenL, givenP := featureCollectionL != nil, featureCollectionP != nil
 
-		// Make sure it's a well-formatted GeoJSON Object
-		geoJsonObj, err := geojson.UnmarshalFeatureCollection([]byte(split_building_limits))
-		if ok := handleInternalServerError(ctx, err); !ok {
+		// Fall back on the stored counterpart
+		if featureCollectionL == nil {
+			buildingLimits, err := model.GetBuildingLimits(project.ID)
+			if featureCollectionL, ok = unmarshalStoredFeatureCollection(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "building-limits")), buildingLimits.Data, err); !ok {
+				return
+			}
+		}
+
+		if featureCollectionP == nil {
+			heightPlateaux, err := model.GetHeightPlateaux(project.ID)
+			if featureCollectionP, ok = unmarshalStoredFeatureCollection(context.WithValue(ctx, ctxKeyLogger, log.WithValues("object-name", "height-plateaux")), heightPlateaux.Data, err); !ok {
+				return
+			}
+		}
+
+		if givenL {
+			precision.Snap(featureCollectionL, featureCollectionP)
+		}
+
+		if givenP {
+			precision.Snap(featureCollectionP, featureCollectionL)
+		}
+
+		handleValidationReport(ctx, featureCollectionL, featureCollectionP)
+	})
+
+	// MARK: POST /validate (project-less)
+	ginRouter.POST("/validate", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "validation")
+
+		// Context business logic, cancelled once the client goes away
+		ctx := gin.Request.Context()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+		ctx = context.WithValue(ctx, ctxKeyDesignRuleEngine, construction.NewDesignRuleEngine(construction.WithTimeout(validationTimeout)))
+
+		featureCollectionL, featureCollectionP, ok := bindValidateRequest(ctx)
+		if !ok {
 			return
 		}
 
-		gin.JSON(http.StatusOK, ginAPI.H{"data": *geoJsonObj})
+		handleValidationReport(ctx, featureCollectionL, featureCollectionP)
 	})
 }
 
@@ -283,6 +416,10 @@ func handleInternalServerError(ctx context.Context, err error) (ok bool) {
 			return
 		}
 
+		if processed := handleProjectNotFound(ctx, err); processed {
+			return
+		}
+
 		// Deal with *unknown*
 		log.Error(err, "failed to get the model")
 		gin.JSON(http.StatusInternalServerError, ginAPI.H{
@@ -324,24 +461,60 @@ func handleNotFound(ctx context.Context, err error) (notFound bool, ok bool) {
 	return false, handleInternalServerError(ctx, err)
 }
 
-// Serialize all violations
-func handleDesignRuleViolations(ctx context.Context, violations []error) {
-	// log := ctx.Value(ctxKeyLogger).(logr.Logger)
+// Serialize all violations along with the offending features and geometries
+func handleDesignRuleViolations(ctx context.Context, violations []construction.Violation) {
+	log := ctx.Value(ctxKeyLogger).(logr.Logger)
 	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
 
-	errors := []ginAPI.H{}
-	for _, v := range violations {
-		errors = append(errors, ginAPI.H{"reason": v.Error()})
-	}
+	errs, warnings := construction.SplitBySeverity(violations)
+
+	log.V(3).Info("design rules are violated", "violations", len(errs), "warnings", len(warnings))
 
-	gin.JSON(http.StatusUnprocessableEntity, ginAPI.H{
+	gin.JSON(http.StatusUnprocessableEntity, withFixes(gin, ginAPI.H{
 		"message": "One or more design rules are violated",
 		"error": ginAPI.H{
 			"code":   http.StatusUnprocessableEntity,
-			"errors": errors,
+			"errors": errs,
+		},
+		"warnings": designRuleWarnings(warnings),
+	}))
+}
+
+func handleProjectNotFound(ctx context.Context, err error) (processed bool) {
+	log := ctx.Value(ctxKeyLogger).(logr.Logger)
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	if !errors.Is(err, mnemosyne.ErrProjectNotFound) {
+		return false
+	}
+
+	log.V(3).Info("project not found")
+
+	gin.AbortWithStatusJSON(http.StatusNotFound, ginAPI.H{
+		"message": "Project doesn't exist",
+		"error": ginAPI.H{
+			"code": http.StatusNotFound,
+			"errors": []ginAPI.H{
+				{"reason": "ErrProjectNotFound"},
+			},
 		},
 	})
 
+	return true
+}
+
+func handleBadRequest(ctx context.Context, err error) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	gin.AbortWithStatusJSON(http.StatusBadRequest, ginAPI.H{
+		"message": err.Error(),
+		"error": ginAPI.H{
+			"code": http.StatusBadRequest,
+			"errors": []ginAPI.H{
+				{"reason": fmt.Sprintf("%T", GenericApiError{})},
+			},
+		},
+	})
 }
 
 func handleMallformedJSON(ctx context.Context, err error) (processed bool) {
@@ -367,20 +540,480 @@ func handleMallformedJSON(ctx context.Context, err error) (processed bool) {
 	return false
 }
 
+// Updates
+
+// Design rule violations found within the write transaction
+type designRuleViolationsError []construction.Violation
+
+func (e designRuleViolationsError) Error() string {
+	return fmt.Sprintf("%d design rule(s) violated", len(e))
+}
+
+var errBuildingLimitsNotFound = errors.New("building limits don't exist")
+
+// Warnings gathered over the runs of the Design Rule Engine within a single update
+type designRuleWarnings []construction.Violation
+
+// Turn the outcome of a validation into an error, nil unless a design rule is violated with severity error.
+// Warnings are kept either way and reported along with the errors.
+func (w *designRuleWarnings) check(ok bool, violations []construction.Violation, err error) error {
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := construction.SplitBySeverity(violations)
+	*w = append(*w, warnings...)
+
+	if !ok {
+		return designRuleViolationsError(append(errs, *w...))
+	}
+
+	return nil
+}
+
+func (w designRuleWarnings) MarshalJSON() ([]byte, error) {
+	if w == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal([]construction.Violation(w))
+}
+
+// The warnings a revision was accepted with
+func revisionWarnings(revision mnemosyne.Revision) json.RawMessage {
+	if revision.Warnings == "" {
+		return json.RawMessage("[]")
+	}
+
+	return json.RawMessage(revision.Warnings)
+}
+
+// Validates and stores a feature collection as a new revision, see updateBuildingLimits
+type updateFunc func(ctx context.Context, mutate mutateFunc) (mnemosyne.Revision, *geojson.FeatureCollection, bool)
+
+// Derives the new feature collection from the current one, which is nil if the project doesn't have it yet
+type mutateFunc func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error)
+
+// Replace the current feature collection as a whole. Every run gets its own copy, since an update may
+// run more than once and the replacement gets snapped and given feature ids in place.
+func replaceWith(featureCollection *geojson.FeatureCollection) mutateFunc {
+	return func(*geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+		return cloneFeatureCollection(featureCollection)
+	}
+}
+
+// Deep copy a feature collection by a round trip through GeoJSON
+func cloneFeatureCollection(featureCollection *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+	data, err := featureCollection.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return geojson.UnmarshalFeatureCollection(data)
+}
+
+// Validate the building limits against the design rules and store them as a new revision
+func updateBuildingLimits(ctx context.Context, mutate mutateFunc) (revision mnemosyne.Revision, featureCollection *geojson.FeatureCollection, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+	project := ctx.Value(ctxKeyProject).(Project)
+	model := ctx.Value(ctxKeyModel).(mnemosyne.Mnemosyne)
+	dre := ctx.Value(ctxKeyDesignRuleEngine).(*construction.DesignRuleEngine)
+	precision := ctx.Value(ctxKeyPrecision).(construction.Precision)
+
+	// The current and complementary feature collections are read, checked and written over within one transaction
+	revision, err := model.UpdateBuildingLimits(project.ID, authorFromRequest(gin), func(current, complementary *mnemosyne.Revision) (string, string, error) {
+		if err := checkIfMatch(gin, current); err != nil {
+			return "", "", err
+		}
+
+		var err error
+		if featureCollection, err = mutateRevision(current, mutate); err != nil {
+			return "", "", err
+		}
+
+		var featureCollectionComplementary *geojson.FeatureCollection
+		if complementary != nil {
+			if featureCollectionComplementary, err = geojson.UnmarshalFeatureCollection([]byte(complementary.Data)); err != nil {
+				return "", "", err
+			}
+		}
+
+		// Snap onto the precision model of the project before any rule runs, the snapped geometry is what gets stored
+		precision.Snap(featureCollection, featureCollectionComplementary)
+
+		var warnings designRuleWarnings
+
+		// Validate the collection
+		if err := warnings.check(dre.ValidateCollection(ctx, construction.LayerBuildingLimits, featureCollection)); err != nil {
+			return "", "", err
+		}
+
+		if featureCollectionComplementary != nil {
+			// Check design rules for splits
+			if err := warnings.check(dre.ValidateSplits(ctx, featureCollection, featureCollectionComplementary)); err != nil {
+				return "", "", err
+			}
+		}
+
+		return marshalRevision(featureCollection, warnings)
+	})
+	if ok := handleUpdateError(ctx, err); !ok {
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	return revision, featureCollection, true
+}
+
+// Validate the height plateaux against the design rules and store them as a new revision
+func updateHeightPlateaux(ctx context.Context, mutate mutateFunc) (revision mnemosyne.Revision, featureCollection *geojson.FeatureCollection, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+	project := ctx.Value(ctxKeyProject).(Project)
+	model := ctx.Value(ctxKeyModel).(mnemosyne.Mnemosyne)
+	dre := ctx.Value(ctxKeyDesignRuleEngine).(*construction.DesignRuleEngine)
+	precision := ctx.Value(ctxKeyPrecision).(construction.Precision)
+
+	// The current and complementary feature collections are read, checked and written over within one transaction
+	revision, err := model.UpdateHeightPlateaux(project.ID, authorFromRequest(gin), func(current, complementary *mnemosyne.Revision) (string, string, error) {
+		if err := checkIfMatch(gin, current); err != nil {
+			return "", "", err
+		}
+
+		var err error
+		if featureCollection, err = mutateRevision(current, mutate); err != nil {
+			return "", "", err
+		}
+
+		var featureCollectionComplementary *geojson.FeatureCollection
+		if complementary != nil {
+			if featureCollectionComplementary, err = geojson.UnmarshalFeatureCollection([]byte(complementary.Data)); err != nil {
+				return "", "", err
+			}
+		}
+
+		// Snap onto the precision model of the project before any rule runs, the snapped geometry is what gets stored
+		precision.Snap(featureCollection, featureCollectionComplementary)
+
+		var warnings designRuleWarnings
+
+		// Check design rules for collection
+		if err := warnings.check(dre.ValidateCollection(ctx, construction.LayerHeightPlateaux, featureCollection)); err != nil {
+			return "", "", err
+		}
+
+		if featureCollectionComplementary == nil {
+			return "", "", errBuildingLimitsNotFound
+		}
+
+		// Check design rules for splits
+		if err := warnings.check(dre.ValidateSplits(ctx, featureCollectionComplementary, featureCollection)); err != nil {
+			return "", "", err
+		}
+
+		return marshalRevision(featureCollection, warnings)
+	})
+	if ok := handleUpdateError(ctx, err); !ok {
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	return revision, featureCollection, true
+}
+
+// Serialize the data and warnings of a new revision
+func marshalRevision(featureCollection *geojson.FeatureCollection, warnings designRuleWarnings) (data string, warningsData string, err error) {
+	geoJson, err := featureCollection.MarshalJSON()
+	if err != nil {
+		return "", "", err
+	}
+
+	warningsJson, err := json.Marshal(warnings)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(geoJson), string(warningsJson), nil
+}
+
+// Apply mutate to the current revision and make sure every feature has got a stable id
+func mutateRevision(current *mnemosyne.Revision, mutate mutateFunc) (*geojson.FeatureCollection, error) {
+	var featureCollectionCurrent *geojson.FeatureCollection
+
+	if current != nil {
+		var err error
+		if featureCollectionCurrent, err = geojson.UnmarshalFeatureCollection([]byte(current.Data)); err != nil {
+			return nil, err
+		}
+	}
+
+	featureCollection, err := mutate(featureCollectionCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = assignFeatureIDs(featureCollection, featureCollectionCurrent); err != nil {
+		return nil, err
+	}
+
+	return featureCollection, nil
+}
+
+// Map the errors raised within the write transaction onto responses
+func handleUpdateError(ctx context.Context, err error) (ok bool) {
+	log := ctx.Value(ctxKeyLogger).(logr.Logger)
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	var violations designRuleViolationsError
+
+	switch {
+	case err == nil:
+		return true
+
+	case errors.As(err, &violations):
+		handleDesignRuleViolations(ctx, violations)
+
+	case errors.Is(err, errPreconditionFailed):
+		log.V(3).Info("precondition failed", "if-match", gin.GetHeader(headerIfMatch))
+
+		gin.JSON(http.StatusPreconditionFailed, ginAPI.H{
+			"message": "The object has been changed in the meantime",
+			"error": ginAPI.H{
+				"code": http.StatusPreconditionFailed,
+				"errors": []ginAPI.H{
+					{"reason": "ErrPreconditionFailed"},
+				},
+			},
+		})
+
+	case errors.Is(err, errFeatureNotFound):
+		gin.JSON(http.StatusNotFound, ginAPI.H{
+			"message": "Feature doesn't exist",
+			"error": ginAPI.H{
+				"code": http.StatusNotFound,
+				"errors": []ginAPI.H{
+					{"reason": "ErrFeatureNotFound"},
+				},
+			},
+		})
+
+	case errors.Is(err, errInvalidFeatureID):
+		handleBadRequest(ctx, err)
+
+	case errors.Is(err, errPatchConflict):
+		gin.JSON(http.StatusConflict, ginAPI.H{
+			"message": err.Error(),
+			"error": ginAPI.H{
+				"code": http.StatusConflict,
+				"errors": []ginAPI.H{
+					{"reason": "ErrPatchConflict"},
+				},
+			},
+		})
+
+	case errors.Is(err, mnemosyne.ErrConflict):
+		gin.JSON(http.StatusConflict, ginAPI.H{
+			"message": "The object keeps being changed by others, try again",
+			"error": ginAPI.H{
+				"code": http.StatusConflict,
+				"errors": []ginAPI.H{
+					{"reason": "ErrConflict"},
+				},
+			},
+		})
+
+	case errors.Is(err, errPatchResult):
+		gin.JSON(http.StatusUnprocessableEntity, ginAPI.H{
+			"message": err.Error(),
+			"error": ginAPI.H{
+				"code": http.StatusUnprocessableEntity,
+				"errors": []ginAPI.H{
+					{"reason": "ErrPatchResult"},
+				},
+			},
+		})
+
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Info("validation timed out", "timeout", validationTimeout)
+
+		gin.JSON(http.StatusServiceUnavailable, ginAPI.H{
+			"message": "Validation took too long",
+			"error": ginAPI.H{
+				"code": http.StatusServiceUnavailable,
+				"errors": []ginAPI.H{
+					{"reason": "ErrValidationTimeout"},
+				},
+			},
+		})
+
+	case errors.Is(err, errBuildingLimitsNotFound):
+		gin.JSON(http.StatusUnprocessableEntity, ginAPI.H{
+			"message": "Building limits don't exist",
+			"error": ginAPI.H{
+				"code": http.StatusUnprocessableEntity,
+				"errors": []ginAPI.H{
+					{"reason": fmt.Sprintf("%T", GenericApiError{})},
+				},
+			},
+		})
+
+	default:
+		return handleInternalServerError(ctx, err)
+	}
+
+	return false
+}
+
+// The author of a change is whoever the client claims to be
+func authorFromRequest(gin *ginAPI.Context) string {
+	if author := gin.GetHeader(headerAuthor); author != "" {
+		return author
+	}
+
+	return defaultAuthor
+}
+
+// Dry-run validation
+
+// Bind the building limits and height plateaux of the validation request; either of them may be missing
+func bindValidateRequest(ctx context.Context) (featureCollectionL, featureCollectionP *geojson.FeatureCollection, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	body, err := io.ReadAll(gin.Request.Body)
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return nil, nil, false
+	}
+
+	var request ValidateRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		if processed := handleMallformedJSON(ctx, err); !processed {
+			handleBadRequest(ctx, err)
+		}
+
+		return nil, nil, false
+	}
+
+	if len(request.BuildingLimits) == 0 && len(request.HeightPlateaux) == 0 {
+		gin.JSON(http.StatusBadRequest, ginAPI.H{
+			"message": "Neither building limits nor height plateaux are provided",
+			"error": ginAPI.H{
+				"code": http.StatusBadRequest,
+				"errors": []ginAPI.H{
+					{"reason": fmt.Sprintf("%T", GenericApiError{})},
+				},
+			},
+		})
+
+		return nil, nil, false
+	}
+
+	// Make sure those are well-formatted GeoJSON Objects, a valid JSON document may still be an invalid one
+	if len(request.BuildingLimits) != 0 {
+		featureCollectionL, err = geojson.UnmarshalFeatureCollection(request.BuildingLimits)
+		if err != nil {
+			handleBadRequest(ctx, err)
+			return nil, nil, false
+		}
+	}
+
+	if len(request.HeightPlateaux) != 0 {
+		featureCollectionP, err = geojson.UnmarshalFeatureCollection(request.HeightPlateaux)
+		if err != nil {
+			handleBadRequest(ctx, err)
+			return nil, nil, false
+		}
+	}
+
+	return featureCollectionL, featureCollectionP, true
+}
+
+// Unmarshal a feature collection fetched from the model. A missing collection is not an error.
+func unmarshalStoredFeatureCollection(ctx context.Context, data string, err error) (featureCollection *geojson.FeatureCollection, ok bool) {
+	if err == mnemosyne.ErrNotFound {
+		return nil, true
+	}
+
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return nil, false
+	}
+
+	featureCollection, err = geojson.UnmarshalFeatureCollection([]byte(data))
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return nil, false
+	}
+
+	return featureCollection, true
+}
+
+// Run every applicable design rule and serialize the full report. Nothing is persisted.
+func handleValidationReport(ctx context.Context, featureCollectionL, featureCollectionP *geojson.FeatureCollection) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+	dre := ctx.Value(ctxKeyDesignRuleEngine).(*construction.DesignRuleEngine)
+
+	violations := []construction.Violation{}
+
+	if featureCollectionL != nil {
+		_, v, err := dre.ValidateCollection(ctx, construction.LayerBuildingLimits, featureCollectionL)
+		if ok := handleUpdateError(ctx, err); !ok {
+			return
+		}
+		violations = append(violations, v...)
+	}
+
+	if featureCollectionP != nil {
+		_, v, err := dre.ValidateCollection(ctx, construction.LayerHeightPlateaux, featureCollectionP)
+		if ok := handleUpdateError(ctx, err); !ok {
+			return
+		}
+		violations = append(violations, v...)
+	}
+
+	if featureCollectionL != nil && featureCollectionP != nil {
+		_, v, err := dre.ValidateSplits(ctx, featureCollectionL, featureCollectionP)
+		if ok := handleUpdateError(ctx, err); !ok {
+			return
+		}
+		violations = append(violations, v...)
+	}
+
+	errs, warnings := construction.SplitBySeverity(violations)
+
+	gin.JSON(http.StatusOK, ginAPI.H{
+		"data": ginAPI.H{
+			"valid":      len(errs) == 0,
+			"violations": errs,
+			"warnings":   warnings,
+		},
+	})
+}
+
 // MARK: Middlewares
 
 func projectIDMiddleware(gin *ginAPI.Context) {
 	var project Project
 
 	log := logger.FromContext(gin)
+	model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+	// Context business logic
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, ctxKeyLogger, log)
+	ctx = context.WithValue(ctx, ctxKeyGin, gin)
 
 	err := gin.ShouldBindUri(&project)
 	if err != nil {
-		gin.JSON(400, ginAPI.H{"msg": "bla"})
+		handleBadRequest(ctx, fmt.Errorf("project id must be a UUID: %w", err))
+		return
+	}
+
+	// Make sure the project exists
+	stored, err := model.GetProject(project.ID)
+	if ok := handleInternalServerError(context.WithValue(ctx, ctxKeyLogger, log.WithValues("project-id", project.ID)), err); !ok {
+		gin.Abort()
 		return
 	}
 
 	gin.Set("project", project)
+	gin.Set("designRules", stored.DesignRules)
+	gin.Set("precision", stored.Precision)
+	gin.Set("epsg", stored.EPSG)
 	gin.Set("log", log.WithValues("project-id", project.ID))
 
 	gin.Next()
@@ -389,16 +1022,18 @@ func projectIDMiddleware(gin *ginAPI.Context) {
 func makeUpdateContext(gin *ginAPI.Context, objectNameKey string, objectNameValue string) context.Context {
 	log := logger.FromContext(gin).WithValues(objectNameKey, objectNameValue)
 	project := gin.MustGet("project").(Project)
-	model := gin.MustGet("model").(*mnemosyne.Mnemosyne)
-	dre := construction.NewDesignRuleEngine()
+	model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+	dre := newDesignRuleEngine(log, gin.MustGet("designRules").(string), withProjectCRS(log, gin.MustGet("epsg").(int))...)
+	precision := newPrecision(log, gin.MustGet("precision").(string))
 
-	// Context business logic
-	ctx := context.Background()
+	// Context business logic, cancelled once the client goes away
+	ctx := gin.Request.Context()
 	ctx = context.WithValue(ctx, ctxKeyLogger, log)
 	ctx = context.WithValue(ctx, ctxKeyGin, gin)
 	ctx = context.WithValue(ctx, ctxKeyProject, project)
 	ctx = context.WithValue(ctx, ctxKeyModel, model)
 	ctx = context.WithValue(ctx, ctxKeyDesignRuleEngine, dre)
+	ctx = context.WithValue(ctx, ctxKeyPrecision, precision)
 
 	return ctx
 }
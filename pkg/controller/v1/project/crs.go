@@ -0,0 +1,108 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"net/http"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/construction"
+	"github.com/paaloeye/texel-api/pkg/logger"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// Register the endpoints of the metric CRS of a project
+func registerCRS(api *ginAPI.RouterGroup) {
+
+	// MARK: GET /crs
+	api.GET("/crs", func(gin *ginAPI.Context) {
+		gin.JSON(http.StatusOK, ginAPI.H{"data": CRSResource{EPSG: gin.MustGet("epsg").(int)}})
+	})
+
+	// MARK: PUT /crs
+	api.PUT("/crs", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "crs")
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		crs := CRSResource{}
+		if err := gin.ShouldBindJSON(&crs); err != nil {
+			if processed := handleMallformedJSON(ctx, err); processed {
+				return
+			}
+
+			handleBadRequest(ctx, err)
+			return
+		}
+
+		// Zero goes back to detecting the UTM zone from the data
+		if crs.EPSG != 0 {
+			if _, err := construction.NewProjection(crs.EPSG); err != nil {
+				handleBadRequest(ctx, err)
+				return
+			}
+		}
+
+		err := model.UpdateEPSG(project.ID, crs.EPSG)
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		log.V(3).Info("CRS updated", "epsg", crs.EPSG)
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": crs})
+	})
+}
+
+// MARK: Private API
+
+// newProjection picks the projection the geometries of a project are measured in:
+// the EPSG code of the project if any, the UTM zone of the data otherwise
+func newProjection(log logr.Logger, epsg int, featureCollections ...*geojson.FeatureCollection) construction.Projection {
+	if epsg != 0 {
+		projection, err := construction.NewProjection(epsg)
+		if err == nil {
+			return projection
+		}
+
+		log.Error(err, "stored EPSG code is unsupported, falling back on the UTM zone of the data")
+	}
+
+	return construction.DetectProjection(featureCollections...)
+}
+
+// The design rules measure areas in the CRS of the project, in the UTM zone of the data if it hasn't got one
+func withProjectCRS(log logr.Logger, epsg int) []construction.DesignRuleEngineOption {
+	if epsg == 0 {
+		return nil
+	}
+
+	projection, err := construction.NewProjection(epsg)
+	if err != nil {
+		log.Error(err, "stored EPSG code is unsupported, falling back on the UTM zone of the data")
+		return nil
+	}
+
+	return []construction.DesignRuleEngineOption{construction.WithProjection(projection)}
+}
+
+// The areas and perimeters of the features of a collection, in square metres and metres
+func newMeasurementsResource(projection construction.Projection, measurements []construction.Measurement) ginAPI.H {
+	return ginAPI.H{
+		"crs":      projection.String(),
+		"features": measurements,
+	}
+}
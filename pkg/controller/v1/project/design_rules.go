@@ -0,0 +1,100 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/construction"
+	"github.com/paaloeye/texel-api/pkg/logger"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
+// Register the endpoints of the design rule settings of a project
+func registerDesignRules(api *ginAPI.RouterGroup) {
+
+	// MARK: GET /design_rules
+	api.GET("/design_rules", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "design_rules")
+		designRules := gin.MustGet("designRules").(string)
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": newDesignRuleResources(newDesignRuleEngine(log, designRules))})
+	})
+
+	// MARK: PUT /design_rules
+	api.PUT("/design_rules", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "design_rules")
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		ruleSet := construction.RuleSet{}
+		if err := gin.ShouldBindJSON(&ruleSet); err != nil {
+			if processed := handleMallformedJSON(ctx, err); processed {
+				return
+			}
+
+			handleBadRequest(ctx, err)
+			return
+		}
+
+		// Unlike the stored settings, the request must only refer to rules and parameters the engine knows of
+		dre := construction.NewDesignRuleEngine()
+		if err := dre.Configure(ruleSet); err != nil {
+			handleBadRequest(ctx, err)
+			return
+		}
+
+		designRules, err := json.Marshal(ruleSet)
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		err = model.UpdateDesignRules(project.ID, string(designRules))
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		log.V(3).Info("design rules updated", "rules", len(ruleSet))
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": newDesignRuleResources(dre)})
+	})
+}
+
+// MARK: Private API
+
+// newDesignRuleEngine builds the engine of a project out of its stored design rule settings
+func newDesignRuleEngine(log logr.Logger, designRules string, opts ...construction.DesignRuleEngineOption) *construction.DesignRuleEngine {
+	var ruleSet construction.RuleSet
+
+	if err := json.Unmarshal([]byte(designRules), &ruleSet); err != nil {
+		log.Error(err, "stored design rules are malformed, falling back on the defaults")
+		ruleSet = nil
+	}
+
+	opts = append([]construction.DesignRuleEngineOption{construction.WithTimeout(validationTimeout), construction.WithRuleSet(ruleSet)}, opts...)
+	return construction.NewDesignRuleEngine(opts...)
+}
+
+func newDesignRuleResources(dre *construction.DesignRuleEngine) []DesignRuleResource {
+	resources := []DesignRuleResource{}
+
+	for _, rule := range dre.Rules() {
+		settings, _ := dre.Settings(rule.Name())
+		resources = append(resources, NewDesignRuleResource(rule, settings))
+	}
+
+	return resources
+}
@@ -0,0 +1,86 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+var errPreconditionFailed = errors.New("precondition failed")
+
+// The revision number is all it takes to tell two versions of an object apart
+func revisionETag(revision mnemosyne.Revision) string {
+	return `"` + strconv.Itoa(revision.Revision) + `"`
+}
+
+// Check If-Match against the current revision, nil if the project doesn't have the object yet.
+// Runs within the write transaction so nobody can sneak in between the check and the write.
+func checkIfMatch(gin *ginAPI.Context, current *mnemosyne.Revision) error {
+	header := gin.GetHeader(headerIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	// Neither a list of tags nor "*" match a missing object
+	if current == nil {
+		return errPreconditionFailed
+	}
+
+	// Strong comparison, see RFC 9110 Section 13.1.1
+	if !etagMatches(header, revisionETag(*current), false) {
+		return errPreconditionFailed
+	}
+
+	return nil
+}
+
+// Check If-None-Match against the current revision
+func notModified(gin *ginAPI.Context, revision mnemosyne.Revision) bool {
+	header := gin.GetHeader(headerIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	// Weak comparison, see RFC 9110 Section 13.1.2
+	return etagMatches(header, revisionETag(revision), true)
+}
+
+// Match etag against a header value which is either "*" or a comma-separated list of entity tags
+func etagMatches(header string, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
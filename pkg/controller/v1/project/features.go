@@ -0,0 +1,279 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/paaloeye/texel-api/pkg/logger"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	errFeatureNotFound  = errors.New("feature not found")
+	errInvalidFeatureID = errors.New("invalid feature id")
+)
+
+// Register the single feature endpoints of an object under path, e.g. /building_limits/features/:feature_id.
+// Every change is validated along with the rest of the collection and the complementary one.
+func registerFeatures(api *ginAPI.RouterGroup, path string, object mnemosyne.Object, update updateFunc) {
+
+	// MARK: GET /<object>/features/:feature_id
+	api.GET(path+"/features/:feature_id", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", object)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+		featureID := gin.Param("feature_id")
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		var revision mnemosyne.Revision
+		var err error
+
+		switch object {
+		case mnemosyne.ObjectBuildingLimits:
+			revision, err = model.GetBuildingLimits(project.ID)
+		case mnemosyne.ObjectHeightPlateaux:
+			revision, err = model.GetHeightPlateaux(project.ID)
+		}
+
+		featureCollection, ok := unmarshalStoredFeatureCollection(ctx, revision.Data, err)
+		if !ok {
+			return
+		}
+
+		index := -1
+		if featureCollection != nil {
+			index = featureIndex(featureCollection, featureID)
+		}
+
+		if ok := handleUpdateError(ctx, featureFound(index)); !ok {
+			return
+		}
+
+		gin.Header(headerETag, revisionETag(revision))
+		if notModified(gin, revision) {
+			gin.Status(http.StatusNotModified)
+			return
+		}
+
+		// Measured in the projection of the whole collection, as the collection is
+		projection := newProjection(log, gin.MustGet("epsg").(int), featureCollection)
+
+		gin.JSON(http.StatusOK, ginAPI.H{
+			"data":        *featureCollection.Features[index],
+			"revision":    NewRevisionResource(revision),
+			"measurement": projection.MeasureFeature(index, featureCollection.Features[index]),
+			"crs":         projection.String(),
+		})
+	})
+
+	// MARK: PUT /<object>/features/:feature_id
+	api.PUT(path+"/features/:feature_id", func(gin *ginAPI.Context) {
+		ctx := makeUpdateContext(gin, "object-name", string(object))
+		featureID := gin.Param("feature_id")
+
+		body, err := io.ReadAll(gin.Request.Body)
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		// Make sure it's a well-formatted GeoJSON Feature
+		feature, err := geojson.UnmarshalFeature(body)
+		if err != nil {
+			if processed := handleMallformedJSON(ctx, err); !processed {
+				handleBadRequest(ctx, fmt.Errorf("malformed GeoJSON feature: %w", err))
+			}
+
+			return
+		}
+
+		if feature.ID != nil && featureIDString(feature.ID) != featureID {
+			handleBadRequest(ctx, fmt.Errorf("%w: feature id %q doesn't match the path", errInvalidFeatureID, featureIDString(feature.ID)))
+			return
+		}
+		feature.ID = featureID
+
+		// Replace the feature or append a new one. The update may run more than once, each run starts over
+		// with its own copy of the feature.
+		var created bool
+		revision, featureCollection, ok := update(ctx, func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+			if current == nil {
+				current = geojson.NewFeatureCollection()
+			}
+
+			feature, err := cloneFeature(feature)
+			if err != nil {
+				return nil, err
+			}
+
+			index := featureIndex(current, featureID)
+			created = index < 0
+
+			if created {
+				current.Append(feature)
+			} else {
+				current.Features[index] = feature
+			}
+
+			return current, nil
+		})
+		if !ok {
+			return
+		}
+
+		// The stored feature, i.e. snapped onto the precision model of the project
+		feature = featureCollection.Features[featureIndex(featureCollection, featureID)]
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+
+		gin.Header(headerETag, revisionETag(revision))
+		gin.JSON(status, ginAPI.H{
+			"data":     *feature,
+			"revision": NewRevisionResource(revision),
+			"warnings": revisionWarnings(revision),
+		})
+	})
+
+	// MARK: DELETE /<object>/features/:feature_id
+	api.DELETE(path+"/features/:feature_id", func(gin *ginAPI.Context) {
+		ctx := makeUpdateContext(gin, "object-name", string(object))
+		featureID := gin.Param("feature_id")
+
+		revision, _, ok := update(ctx, func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+			index := -1
+			if current != nil {
+				index = featureIndex(current, featureID)
+			}
+
+			if err := featureFound(index); err != nil {
+				return nil, err
+			}
+
+			current.Features = append(current.Features[:index], current.Features[index+1:]...)
+			return current, nil
+		})
+		if !ok {
+			return
+		}
+
+		gin.Header(headerETag, revisionETag(revision))
+		gin.Status(http.StatusNoContent)
+	})
+}
+
+// MARK: Private API
+
+// Make sure no id is used twice and give every feature without an id one. A feature left without an id,
+// e.g. by a client replacing the collection as a whole, keeps the id of the stored feature of the same geometry
+// so that ids stay stable across uploads, or gets a new one.
+func assignFeatureIDs(featureCollection, current *geojson.FeatureCollection) error {
+	seen := make(map[string]bool, len(featureCollection.Features))
+
+	for _, feature := range featureCollection.Features {
+		if feature.ID == nil || feature.ID == "" {
+			continue
+		}
+
+		id := featureIDString(feature.ID)
+		if seen[id] {
+			return fmt.Errorf("%w: %q is used by more than one feature", errInvalidFeatureID, id)
+		}
+		seen[id] = true
+	}
+
+	stored := map[string][]any{}
+	if current != nil {
+		for _, feature := range current.Features {
+			if key := geometryKey(feature); feature.ID != nil && key != "" && !seen[featureIDString(feature.ID)] {
+				stored[key] = append(stored[key], feature.ID)
+			}
+		}
+	}
+
+	for _, feature := range featureCollection.Features {
+		if feature.ID != nil && feature.ID != "" {
+			continue
+		}
+
+		feature.ID = mnemosyne.NewUUID()
+
+		if key := geometryKey(feature); key != "" && len(stored[key]) != 0 {
+			feature.ID, stored[key] = stored[key][0], stored[key][1:]
+		}
+	}
+
+	return nil
+}
+
+// geometryKey identifies the geometry of a feature by its GeoJSON encoding, empty if it has none
+func geometryKey(feature *geojson.Feature) string {
+	if feature.Geometry == nil {
+		return ""
+	}
+
+	data, err := geojson.NewGeometry(feature.Geometry).MarshalJSON()
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// Deep copy a feature by a round trip through GeoJSON
+func cloneFeature(feature *geojson.Feature) (*geojson.Feature, error) {
+	data, err := feature.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return geojson.UnmarshalFeature(data)
+}
+
+func featureIndex(featureCollection *geojson.FeatureCollection, featureID string) int {
+	for i, feature := range featureCollection.Features {
+		if feature.ID != nil && featureIDString(feature.ID) == featureID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func featureFound(index int) error {
+	if index < 0 {
+		return errFeatureNotFound
+	}
+
+	return nil
+}
+
+// GeoJSON allows both strings and numbers as feature ids
+func featureIDString(id any) string {
+	switch id := id.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	default:
+		return fmt.Sprint(id)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/construction"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
+const playgroundProjectID = "feedface-cafe-beef-feed-facecafebeef"
+
+// Building limits stored before the winding order was checked: the exterior ring of "legacy" is clockwise
+const legacyBuildingLimits = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "id": "legacy", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10, 60], [10, 60.001], [10.001, 60.001], [10.001, 60], [10, 60]]]}},
+	{"type": "Feature", "id": "other", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10.01, 60], [10.011, 60], [10.011, 60.001], [10.01, 60.001], [10.01, 60]]]}}
+]}`
+
+// A counter-clockwise replacement of "other"
+const otherBuildingLimit = `{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10.01, 60], [10.012, 60], [10.012, 60.001], [10.01, 60.001], [10.01, 60]]]}}`
+
+func TestEditLegacyClockwiseRings(t *testing.T) {
+	model := mnemosyne.NewMemory(logr.Discard())
+	storeLegacyBuildingLimits(t, model)
+	router := newTestRouter(model)
+
+	// The stored clockwise ring blocks edits of other features
+	response := serve(router, http.MethodPut, "/v1/projects/"+playgroundProjectID+"/building_limits/features/other", otherBuildingLimit)
+	if response.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("PUT feature: got %d, want %d: %s", response.Code, http.StatusUnprocessableEntity, response.Body)
+	}
+
+	if reasons := violationReasons(t, response); len(reasons) != 1 || reasons[0] != construction.DesignRuleViolationWindingOrder.String() {
+		t.Errorf("PUT feature: got violations %v, want a single %s", reasons, construction.DesignRuleViolationWindingOrder)
+	}
+
+	// Until the collection is rewound
+	response = serveWithContentType(router, http.MethodPatch, "/v1/projects/"+playgroundProjectID+"/building_limits?rewind=true", "application/merge-patch+json", "{}")
+	if response.Code != http.StatusOK {
+		t.Fatalf("PATCH ?rewind=true: got %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+	}
+
+	response = serve(router, http.MethodPut, "/v1/projects/"+playgroundProjectID+"/building_limits/features/other", otherBuildingLimit)
+	if response.Code != http.StatusOK {
+		t.Fatalf("PUT feature: got %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+	}
+}
+
+func TestPutMalformedFeature(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "syntax error", body: `{"type": "Feature",`},
+		{name: "not a feature", body: `{"type": "FeatureCollection", "features": []}`},
+		{name: "unknown geometry", body: `{"type": "Feature", "properties": {}, "geometry": {"type": "Circle", "coordinates": [10, 60]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(mnemosyne.NewMemory(logr.Discard()))
+
+			response := serve(router, http.MethodPut, "/v1/projects/"+playgroundProjectID+"/building_limits/features/other", tt.body)
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("got %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+			}
+		})
+	}
+}
+
+// MARK: Private API
+
+func newTestRouter(model mnemosyne.Mnemosyne) *ginAPI.Engine {
+	ginAPI.SetMode(ginAPI.TestMode)
+
+	router := ginAPI.New()
+	router.Use(func(gin *ginAPI.Context) {
+		gin.Set("log", logr.Discard())
+		gin.Set("model", model)
+		gin.Next()
+	})
+
+	Register(router.Group("/v1"))
+
+	return router
+}
+
+// storeLegacyBuildingLimits writes the building limits as they are, bypassing the design rules
+func storeLegacyBuildingLimits(t *testing.T, model mnemosyne.Mnemosyne) {
+	t.Helper()
+
+	_, err := model.UpdateBuildingLimits(playgroundProjectID, "legacy", func(_, _ *mnemosyne.Revision) (string, string, error) {
+		return legacyBuildingLimits, "[]", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	return serveWithContentType(router, method, target, "application/json", body)
+}
+
+func serveWithContentType(router http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	return response
+}
+
+// violationReasons lists the rules behind the design rule violations of a rejected write
+func violationReasons(t *testing.T, response *httptest.ResponseRecorder) (reasons []string) {
+	t.Helper()
+
+	var document struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(response.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, violation := range document.Error.Errors {
+		reasons = append(reasons, violation.Reason)
+	}
+
+	return reasons
+}
@@ -0,0 +1,219 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/construction"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json" // RFC 7396
+	contentTypeJSONPatch  = "application/json-patch+json"  // RFC 6902
+
+	emptyFeatureCollection = `{"type": "FeatureCollection", "features": []}`
+)
+
+var (
+	errPatchConflict = errors.New("patch can't be applied")
+	errPatchResult   = errors.New("patched document isn't a feature collection")
+)
+
+// Bind a PATCH request to the mutation it asks for. With ?rewind=true the rings of the resulting
+// collection are rewound to RFC 7946 instead of being rejected by the Design Rule Engine.
+// With ?repair=true its geometries are repaired altogether, see construction.Repair.
+func bindPatch(ctx context.Context, layer construction.Layer) (mutate mutateFunc, ok bool) {
+	rewind, ok := bindQueryBool(ctx, "rewind")
+	if !ok {
+		return nil, false
+	}
+
+	repair, ok := bindQueryBool(ctx, "repair")
+	if !ok {
+		return nil, false
+	}
+
+	if mutate, ok = bindPatchBody(ctx); !ok {
+		return nil, false
+	}
+
+	switch {
+	case repair:
+		return repairWith(ctx, layer, mutate), true
+	case rewind:
+		return rewindWith(ctx, mutate), true
+	default:
+		return mutate, true
+	}
+}
+
+// MARK: Private API
+
+// The fixes applied by ?repair=true, reported along with the outcome of the PATCH
+type geometryFixes []construction.Fix
+
+func (f geometryFixes) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal([]construction.Fix(f))
+}
+
+// Add the fixes applied to the request, if it asked for a repair, to a response
+func withFixes(gin *ginAPI.Context, response ginAPI.H) ginAPI.H {
+	if fixes, ok := gin.Get("fixes"); ok {
+		response["fixes"] = fixes
+	}
+
+	return response
+}
+
+// Bind the body of a PATCH request. A merge patch or a JSON patch is applied to the current
+// feature collection, anything else replaces it as a whole. Mind that a merge patch can't reach into
+// arrays (RFC 7396, section 2): one carrying "features" replaces all of them.
+func bindPatchBody(ctx context.Context) (mutate mutateFunc, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	body, err := io.ReadAll(gin.Request.Body)
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return nil, false
+	}
+
+	switch gin.ContentType() {
+	case contentTypeMergePatch:
+		// Any JSON document is a valid merge patch
+		var document any
+		if err := json.Unmarshal(body, &document); err != nil {
+			if processed := handleMallformedJSON(ctx, err); !processed {
+				handleBadRequest(ctx, fmt.Errorf("malformed merge patch: %w", err))
+			}
+
+			return nil, false
+		}
+
+		return applyPatch(func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}), true
+
+	case contentTypeJSONPatch:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			if processed := handleMallformedJSON(ctx, err); !processed {
+				handleBadRequest(ctx, fmt.Errorf("malformed JSON patch: %w", err))
+			}
+
+			return nil, false
+		}
+
+		return applyPatch(patch.Apply), true
+
+	default:
+		// Make sure it's a well-formatted GeoJSON Object
+		featureCollection, err := geojson.UnmarshalFeatureCollection(body)
+		if err != nil {
+			if processed := handleMallformedJSON(ctx, err); !processed {
+				handleBadRequest(ctx, fmt.Errorf("the body replaces the stored collection and must be a GeoJSON FeatureCollection, "+
+					"patch single features with %s instead: %w", contentTypeJSONPatch, err))
+			}
+
+			return nil, false
+		}
+
+		return replaceWith(featureCollection), true
+	}
+}
+
+// Rewind the rings of whatever the mutation produces
+func rewindWith(ctx context.Context, mutate mutateFunc) mutateFunc {
+	log := ctx.Value(ctxKeyLogger).(logr.Logger)
+
+	return func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+		featureCollection, err := mutate(current)
+		if err != nil {
+			return nil, err
+		}
+
+		if rewound := construction.Rewind(featureCollection); rewound != 0 {
+			log.V(3).Info("rings rewound", "rings", rewound)
+		}
+
+		return featureCollection, nil
+	}
+}
+
+// Repair the geometries of whatever the mutation produces and keep the fixes for the response
+func repairWith(ctx context.Context, layer construction.Layer, mutate mutateFunc) mutateFunc {
+	log := ctx.Value(ctxKeyLogger).(logr.Logger)
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	gin.Set("fixes", geometryFixes(nil))
+
+	return func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+		featureCollection, err := mutate(current)
+		if err != nil {
+			return nil, err
+		}
+
+		fixes := construction.Repair(layer, featureCollection)
+		log.V(3).Info("geometries repaired", "fixes", len(fixes))
+
+		gin.Set("fixes", geometryFixes(fixes))
+
+		return featureCollection, nil
+	}
+}
+
+// Bind an optional boolean query parameter, false unless given
+func bindQueryBool(ctx context.Context, name string) (value bool, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+
+	value, err := strconv.ParseBool(gin.DefaultQuery(name, "false"))
+	if err != nil {
+		handleBadRequest(ctx, fmt.Errorf("%s must be a boolean: %w", name, err))
+		return false, false
+	}
+
+	return value, true
+}
+
+// Apply a patch to the current feature collection, or to an empty one if there is none yet
+func applyPatch(apply func(doc []byte) ([]byte, error)) mutateFunc {
+	return func(current *geojson.FeatureCollection) (*geojson.FeatureCollection, error) {
+		doc := []byte(emptyFeatureCollection)
+
+		if current != nil {
+			var err error
+			if doc, err = current.MarshalJSON(); err != nil {
+				return nil, err
+			}
+		}
+
+		patched, err := apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errPatchConflict, err)
+		}
+
+		featureCollection, err := geojson.UnmarshalFeatureCollection(patched)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errPatchResult, err)
+		}
+
+		return featureCollection, nil
+	}
+}
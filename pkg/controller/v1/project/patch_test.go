@@ -0,0 +1,70 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
+func TestPatchMalformedBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "merge patch syntax error", contentType: contentTypeMergePatch, body: `{"name": `},
+		{name: "JSON patch syntax error", contentType: contentTypeJSONPatch, body: `[{"op": `},
+		{name: "collection syntax error", contentType: "application/json", body: `{"type": "FeatureCollection",`},
+		{name: "feature instead of a collection", contentType: "application/json", body: otherBuildingLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(mnemosyne.NewMemory(logr.Discard()))
+
+			response := serveWithContentType(router, http.MethodPatch, "/v1/projects/"+playgroundProjectID+"/building_limits", tt.contentType, tt.body)
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("got %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+			}
+		})
+	}
+}
+
+// RFC 7396 replaces arrays as a whole, a merge patch carrying features drops the ones left out
+func TestMergePatchReplacesFeatures(t *testing.T) {
+	model := mnemosyne.NewMemory(logr.Discard())
+	router := newTestRouter(model)
+
+	response := serveWithContentType(router, http.MethodPatch, "/v1/projects/"+playgroundProjectID+"/building_limits?rewind=true", "application/json", legacyBuildingLimits)
+	if response.Code != http.StatusOK {
+		t.Fatalf("PATCH: got %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+	}
+
+	response = serveWithContentType(router, http.MethodPatch, "/v1/projects/"+playgroundProjectID+"/building_limits", contentTypeMergePatch, `{"features": [`+otherBuildingLimit+`]}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("merge patch: got %d, want %d: %s", response.Code, http.StatusOK, response.Body)
+	}
+
+	var document struct {
+		Data struct {
+			Features []json.RawMessage `json:"features"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(response.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(document.Data.Features) != 1 {
+		t.Errorf("got %d features, want 1", len(document.Data.Features))
+	}
+}
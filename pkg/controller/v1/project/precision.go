@@ -0,0 +1,85 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/construction"
+	"github.com/paaloeye/texel-api/pkg/logger"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
+// Register the endpoints of the precision model of a project
+func registerPrecision(api *ginAPI.RouterGroup) {
+
+	// MARK: GET /precision
+	api.GET("/precision", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "precision")
+		precision := gin.MustGet("precision").(string)
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": newPrecision(log, precision)})
+	})
+
+	// MARK: PUT /precision
+	api.PUT("/precision", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", "precision")
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		precision := construction.Precision{}
+		if err := gin.ShouldBindJSON(&precision); err != nil {
+			if processed := handleMallformedJSON(ctx, err); processed {
+				return
+			}
+
+			handleBadRequest(ctx, err)
+			return
+		}
+
+		if err := precision.Validate(); err != nil {
+			handleBadRequest(ctx, err)
+			return
+		}
+
+		data, err := json.Marshal(precision)
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		// Stored geometries are snapped on their next write
+		err = model.UpdatePrecision(project.ID, string(data))
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		log.V(3).Info("precision updated", "grid", precision.Grid, "tolerance", precision.Tolerance)
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": precision})
+	})
+}
+
+// MARK: Private API
+
+// newPrecision decodes the stored precision model of a project
+func newPrecision(log logr.Logger, data string) (precision construction.Precision) {
+	if err := json.Unmarshal([]byte(data), &precision); err != nil {
+		log.Error(err, "stored precision is malformed, falling back on no snapping at all")
+		return construction.Precision{}
+	}
+
+	return precision
+}
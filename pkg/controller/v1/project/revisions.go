@@ -0,0 +1,137 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package project
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	ginAPI "github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/paaloeye/texel-api/pkg/logger"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// Register the version history endpoints of an object under path, e.g. /building_limits/revisions
+func registerRevisions(api *ginAPI.RouterGroup, path string, object mnemosyne.Object, update updateFunc) {
+
+	// MARK: GET /<object>/revisions
+	api.GET(path+"/revisions", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", object)
+		project := gin.MustGet("project").(Project)
+		model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		revisions, err := model.ListRevisions(project.ID, object)
+		if ok := handleInternalServerError(ctx, err); !ok {
+			return
+		}
+
+		resources := make([]RevisionResource, 0, len(revisions))
+		for _, revision := range revisions {
+			resources = append(resources, NewRevisionResource(revision))
+		}
+
+		gin.JSON(http.StatusOK, ginAPI.H{"data": resources})
+	})
+
+	// MARK: GET /<object>/revisions/:revision
+	api.GET(path+"/revisions/:revision", func(gin *ginAPI.Context) {
+		log := logger.FromContext(gin).WithValues("object-name", object)
+
+		// Context business logic
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, ctxKeyLogger, log)
+		ctx = context.WithValue(ctx, ctxKeyGin, gin)
+
+		revision, featureCollection, ok := fetchRevision(ctx, object)
+		if !ok {
+			return
+		}
+
+		gin.JSON(http.StatusOK, ginAPI.H{
+			"data":     *featureCollection,
+			"revision": NewRevisionResource(revision),
+			"warnings": revisionWarnings(revision),
+		})
+	})
+
+	// MARK: POST /<object>/revisions/:revision/restore
+	api.POST(path+"/revisions/:revision/restore", func(gin *ginAPI.Context) {
+		ctx := makeUpdateContext(gin, "object-name", string(object))
+		log := ctx.Value(ctxKeyLogger).(logr.Logger)
+
+		revision, featureCollection, ok := fetchRevision(ctx, object)
+		if !ok {
+			return
+		}
+
+		// Restoring is just another update: it has to pass the design rules as of today
+		restored, featureCollection, ok := update(ctx, replaceWith(featureCollection))
+		if !ok {
+			return
+		}
+
+		log.V(3).Info("revision restored", "revision", revision.Revision, "new-revision", restored.Revision)
+
+		gin.Header(headerETag, revisionETag(restored))
+		gin.JSON(http.StatusOK, ginAPI.H{
+			"data":     *featureCollection,
+			"revision": NewRevisionResource(restored),
+			"warnings": revisionWarnings(restored),
+		})
+	})
+}
+
+// MARK: Private API
+
+// Fetch the revision given by the :revision path parameter
+func fetchRevision(ctx context.Context, object mnemosyne.Object) (revision mnemosyne.Revision, featureCollection *geojson.FeatureCollection, ok bool) {
+	gin := ctx.Value(ctxKeyGin).(*ginAPI.Context)
+	project := gin.MustGet("project").(Project)
+	model := gin.MustGet("model").(mnemosyne.Mnemosyne)
+
+	number, err := strconv.Atoi(gin.Param("revision"))
+	if err != nil || number < 1 {
+		handleBadRequest(ctx, errors.New("revision must be a positive integer"))
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	revision, err = model.GetRevision(project.ID, object, number)
+	if errors.Is(err, mnemosyne.ErrRevisionNotFound) {
+		gin.JSON(http.StatusNotFound, ginAPI.H{
+			"message": "Revision doesn't exist",
+			"error": ginAPI.H{
+				"code": http.StatusNotFound,
+				"errors": []ginAPI.H{
+					{"reason": "ErrRevisionNotFound"},
+				},
+			},
+		})
+
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	featureCollection, err = geojson.UnmarshalFeatureCollection([]byte(revision.Data))
+	if ok := handleInternalServerError(ctx, err); !ok {
+		return mnemosyne.Revision{}, nil, false
+	}
+
+	return revision, featureCollection, true
+}
@@ -6,10 +6,105 @@
 
 package project
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/paaloeye/texel-api/pkg/construction"
+	"github.com/paaloeye/texel-api/pkg/mnemosyne"
+)
+
 type Project struct {
 	ID string `uri:"project_id" binding:"required,uuid"`
 }
 
+// CreateProjectRequest is the body of POST /projects
+type CreateProjectRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Metadata    json.RawMessage `json:"metadata"`
+}
+
+// ProjectResource is the JSON representation of a project
+type ProjectResource struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Metadata    json.RawMessage `json:"metadata"`
+	DesignRules json.RawMessage `json:"design_rules"`
+	Precision   json.RawMessage `json:"precision"`
+	EPSG        int             `json:"epsg"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func NewProjectResource(project mnemosyne.Project) ProjectResource {
+	return ProjectResource{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		Metadata:    json.RawMessage(project.Metadata),
+		DesignRules: json.RawMessage(project.DesignRules),
+		Precision:   json.RawMessage(project.Precision),
+		EPSG:        project.EPSG,
+		CreatedAt:   project.CreatedAt,
+	}
+}
+
+// RevisionResource is the JSON representation of a revision without its data
+type RevisionResource struct {
+	Revision  int       `json:"revision"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewRevisionResource(revision mnemosyne.Revision) RevisionResource {
+	return RevisionResource{
+		Revision:  revision.Revision,
+		Author:    revision.Author,
+		CreatedAt: revision.CreatedAt,
+	}
+}
+
+// DesignRuleResource is the JSON representation of a design rule along with its settings within a project
+type DesignRuleResource struct {
+	Name       string                  `json:"name"`
+	Kind       string                  `json:"kind"` // collection or split
+	Enabled    bool                    `json:"enabled"`
+	Severity   construction.Severity   `json:"severity"`
+	Parameters construction.Parameters `json:"parameters"`
+}
+
+func NewDesignRuleResource(rule construction.Rule, settings construction.RuleSettings) DesignRuleResource {
+	resource := DesignRuleResource{
+		Name:       rule.Name(),
+		Kind:       "collection",
+		Enabled:    settings.Enabled == nil || *settings.Enabled,
+		Severity:   settings.Severity,
+		Parameters: settings.Parameters,
+	}
+
+	if _, ok := rule.(construction.SplitRule); ok {
+		resource.Kind = "split"
+	}
+
+	if resource.Parameters == nil {
+		resource.Parameters = construction.Parameters{}
+	}
+
+	return resource
+}
+
+// CRSResource is the JSON representation of the metric CRS of a project, 0 to detect the UTM zone from the data
+type CRSResource struct {
+	EPSG int `json:"epsg"`
+}
+
+// ValidateRequest is the body of the dry-run validation endpoints
+type ValidateRequest struct {
+	BuildingLimits json.RawMessage `json:"building_limits"`
+	HeightPlateaux json.RawMessage `json:"height_plateaux"`
+}
+
 type GenericApiError struct {
 }
 
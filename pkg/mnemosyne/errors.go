@@ -9,5 +9,9 @@ package mnemosyne
 import "errors"
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound         = errors.New("not found")
+	ErrProjectNotFound  = errors.New("project not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrSchemaTooNew     = errors.New("database schema is newer than the binary")
+	ErrConflict         = errors.New("object keeps being changed concurrently")
 )
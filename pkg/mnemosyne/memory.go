@@ -0,0 +1,321 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const playgroundProjectID = "feedface-cafe-beef-feed-facecafebeef"
+
+func init() {
+	Register(DriverMemory, func(log logr.Logger, _ string) Mnemosyne {
+		return NewMemory(log)
+	})
+}
+
+// Memory keeps everything in memory and forgets it on exit.
+// Good for unit tests and ephemeral environments as it doesn't need CGO.
+type Memory struct {
+	log *logr.Logger
+
+	// A single lock guards the maps, updates are optimistic so that validation runs without it
+	mu        sync.RWMutex
+	projects  map[string]Project
+	revisions map[Object]map[string][]Revision // object -> project id -> revisions, oldest first
+}
+
+func NewMemory(log logr.Logger) *Memory {
+	memory := &Memory{
+		log:      &log,
+		projects: map[string]Project{},
+		revisions: map[Object]map[string][]Revision{
+			ObjectBuildingLimits: {},
+			ObjectHeightPlateaux: {},
+		},
+	}
+
+	// Magic values, same as in the SQL backends
+	memory.projects[playgroundProjectID] = Project{
+		ID:          playgroundProjectID,
+		Name:        "Playground",
+		Description: "Default project used by the integration tests",
+		Metadata:    "{}",
+		DesignRules: "{}",
+		Precision:   "{}",
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	return memory
+}
+
+func (m *Memory) PingContext(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// MARK: Projects
+
+func (m *Memory) CreateProject(name, description, metadata string) (Project, error) {
+	project := Project{
+		ID:          NewUUID(),
+		Name:        name,
+		Description: description,
+		Metadata:    metadata,
+		DesignRules: "{}",
+		Precision:   "{}",
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if project.Metadata == "" {
+		project.Metadata = "{}"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.projects[project.ID] = project
+
+	return project, nil
+}
+
+func (m *Memory) GetProject(projectID string) (Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	project, ok := m.projects[projectID]
+	if !ok {
+		return Project{}, ErrProjectNotFound
+	}
+
+	return project, nil
+}
+
+func (m *Memory) ListProjects() ([]Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	projects := make([]Project, 0, len(m.projects))
+	for _, project := range m.projects {
+		projects = append(projects, project)
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		if !projects[i].CreatedAt.Equal(projects[j].CreatedAt) {
+			return projects[i].CreatedAt.Before(projects[j].CreatedAt)
+		}
+
+		return projects[i].ID < projects[j].ID
+	})
+
+	return projects, nil
+}
+
+// UpdateDesignRules replaces the design rule settings of the project
+func (m *Memory) UpdateDesignRules(projectID string, designRules string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, ok := m.projects[projectID]
+	if !ok {
+		return ErrProjectNotFound
+	}
+
+	project.DesignRules = designRules
+	m.projects[projectID] = project
+
+	return nil
+}
+
+// UpdatePrecision replaces the precision model of the project
+func (m *Memory) UpdatePrecision(projectID string, precision string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, ok := m.projects[projectID]
+	if !ok {
+		return ErrProjectNotFound
+	}
+
+	project.Precision = precision
+	m.projects[projectID] = project
+
+	return nil
+}
+
+// UpdateEPSG replaces the metric CRS of the project
+func (m *Memory) UpdateEPSG(projectID string, epsg int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, ok := m.projects[projectID]
+	if !ok {
+		return ErrProjectNotFound
+	}
+
+	project.EPSG = epsg
+	m.projects[projectID] = project
+
+	return nil
+}
+
+// DeleteProject deletes the project along with its building limits and height plateaux
+func (m *Memory) DeleteProject(projectID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[projectID]; !ok {
+		return ErrProjectNotFound
+	}
+
+	delete(m.projects, projectID)
+	for _, revisions := range m.revisions {
+		delete(revisions, projectID)
+	}
+
+	return nil
+}
+
+// MARK: Building Limits
+
+func (m *Memory) GetBuildingLimits(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectBuildingLimits)
+}
+
+func (m *Memory) UpdateBuildingLimits(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectBuildingLimits, ObjectHeightPlateaux, author, update)
+}
+
+// MARK: Height plateaux
+
+func (m *Memory) GetHeightPlateaux(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectHeightPlateaux)
+}
+
+func (m *Memory) UpdateHeightPlateaux(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectHeightPlateaux, ObjectBuildingLimits, author, update)
+}
+
+// MARK: Revisions
+
+// ListRevisions returns all revisions of the object, oldest first, without their data
+func (m *Memory) ListRevisions(projectID string, object Object) ([]Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := make([]Revision, 0, len(m.revisions[object][projectID]))
+	for _, revision := range m.revisions[object][projectID] {
+		revision.Data, revision.Warnings = "", ""
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a specific revision of the object along with its data
+func (m *Memory) GetRevision(projectID string, object Object, revision int) (Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Revisions are numbered from 1 without gaps
+	revisions := m.revisions[object][projectID]
+	if revision < 1 || revision > len(revisions) {
+		return Revision{}, ErrRevisionNotFound
+	}
+
+	return revisions[revision-1], nil
+}
+
+func (m *Memory) Drop() {}
+
+// MARK: Private API
+
+func (m *Memory) getObject(projectID string, object Object) (Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if current := m.current(projectID, object); current != nil {
+		return *current, nil
+	}
+
+	// The project doesn't have the object yet
+	return Revision{}, ErrNotFound
+}
+
+// updateObject runs update without holding the lock, the design rules may take a while. The outcome is
+// only written if neither the object nor its complement changed in the meantime, otherwise update runs again,
+// up to updateAttempts times.
+func (m *Memory) updateObject(projectID string, object, complement Object, author string, update UpdateFunc) (Revision, error) {
+	for attempt := 0; attempt < updateAttempts; attempt++ {
+		m.mu.RLock()
+		_, ok := m.projects[projectID]
+		current, currentComplement := m.current(projectID, object), m.current(projectID, complement)
+		m.mu.RUnlock()
+
+		if !ok {
+			return Revision{}, ErrProjectNotFound
+		}
+
+		// Nothing gets written if update refuses, e.g. on a design rule violation
+		data, warnings, err := update(current, currentComplement)
+		if err != nil {
+			return Revision{}, err
+		}
+
+		m.mu.Lock()
+
+		if _, ok := m.projects[projectID]; !ok {
+			m.mu.Unlock()
+			return Revision{}, ErrProjectNotFound
+		}
+
+		if !sameRevision(current, m.current(projectID, object)) || !sameRevision(currentComplement, m.current(projectID, complement)) {
+			m.mu.Unlock()
+			continue
+		}
+
+		revision := Revision{
+			Object:    object,
+			Revision:  len(m.revisions[object][projectID]) + 1,
+			Data:      data,
+			Warnings:  warnings,
+			Author:    author,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		m.revisions[object][projectID] = append(m.revisions[object][projectID], revision)
+		m.mu.Unlock()
+
+		return revision, nil
+	}
+
+	return Revision{}, ErrConflict
+}
+
+// current returns a copy of the latest revision or nil. The caller holds the lock.
+func (m *Memory) current(projectID string, object Object) *Revision {
+	revisions := m.revisions[object][projectID]
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	current := revisions[len(revisions)-1]
+	return &current
+}
+
+// sameRevision tells whether both are the same revision or both are missing
+func sameRevision(a, b *Revision) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Revision == b.Revision
+}
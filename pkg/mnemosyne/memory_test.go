@@ -0,0 +1,40 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestMemoryUpdateConflict(t *testing.T) {
+	m := NewMemory(logr.Discard())
+
+	// Every run of the update loses the race against another writer
+	runs := 0
+	_, err := m.UpdateBuildingLimits(playgroundProjectID, "slow", func(_, _ *Revision) (string, string, error) {
+		runs++
+
+		if _, err := m.UpdateBuildingLimits(playgroundProjectID, "fast", func(_, _ *Revision) (string, string, error) {
+			return "{}", "[]", nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		return "{}", "[]", nil
+	})
+
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v, want %v", err, ErrConflict)
+	}
+
+	if runs != updateAttempts {
+		t.Errorf("got %d runs, want %d", runs, updateAttempts)
+	}
+}
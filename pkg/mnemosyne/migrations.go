@@ -0,0 +1,21 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+// A migration brings the schema from version-1 to version.
+// Every SQL backend keeps its own ordered list of migrations.
+// NB: Never edit a released migration, append a new one instead.
+type migration struct {
+	version int
+	name    string
+	up      string
+}
+
+// schemaVersion is the latest schema version known to this binary
+func schemaVersion(migrations []migration) int {
+	return migrations[len(migrations)-1].version
+}
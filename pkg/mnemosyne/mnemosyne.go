@@ -0,0 +1,111 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Why Mnemosyne? -- https://en.wikipedia.org/wiki/Mnemosyne
+ */
+
+package mnemosyne
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+	DriverPostgres = "postgres"
+
+	DefaultDriver       = DriverSQLite
+	DefaultDatabasePath = "tmp/mnemosyne.db"
+)
+
+// Mnemosyne is the storage of projects, their building limits and height plateaux
+type Mnemosyne interface {
+	PingContext(ctx context.Context) error
+
+	// Projects
+	CreateProject(name, description, metadata string) (Project, error)
+	GetProject(projectID string) (Project, error)
+	ListProjects() ([]Project, error)
+	DeleteProject(projectID string) error
+
+	// Design rule settings of a project, kept as an opaque JSON object
+	UpdateDesignRules(projectID string, designRules string) error
+
+	// Precision model of a project, kept as an opaque JSON object
+	UpdatePrecision(projectID string, precision string) error
+
+	// Metric CRS of a project as an EPSG code, 0 to detect it from the data
+	UpdateEPSG(projectID string, epsg int) error
+
+	// Building limits and height plateaux. Get returns ErrNotFound if the project doesn't have the object yet.
+	// Update runs update and writes its outcome as a new revision in one transaction.
+	GetBuildingLimits(projectID string) (Revision, error)
+	UpdateBuildingLimits(projectID string, author string, update UpdateFunc) (Revision, error)
+	GetHeightPlateaux(projectID string) (Revision, error)
+	UpdateHeightPlateaux(projectID string, author string, update UpdateFunc) (Revision, error)
+
+	// Revisions
+	ListRevisions(projectID string, object Object) ([]Revision, error)
+	GetRevision(projectID string, object Object, revision int) (Revision, error)
+
+	Drop()
+}
+
+// Driver opens a backend given its data source, e.g. the database path. It panics on failure.
+type Driver func(log logr.Logger, dataSource string) Mnemosyne
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// Register makes a backend available by name. Backends register themselves in init().
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if _, exists := drivers[name]; exists {
+		panic(fmt.Sprintf("mnemosyne: driver %q is already registered", name))
+	}
+
+	drivers[name] = driver
+}
+
+// Drivers lists the names of the registered backends
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New opens the backend registered as driverName
+func New(log logr.Logger, driverName string, dataSource string) Mnemosyne {
+	driversMu.RLock()
+	driver, ok := drivers[driverName]
+	driversMu.RUnlock()
+
+	if !ok {
+		err := fmt.Errorf("mnemosyne: unknown driver %q (available: %v)", driverName, Drivers())
+		log.Error(err, "failed to open the database")
+		panic(err)
+	}
+
+	return driver(log.WithValues("driver", driverName), dataSource)
+}
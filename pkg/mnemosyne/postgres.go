@@ -0,0 +1,472 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	_ "github.com/lib/pq"
+)
+
+const (
+	postgresCreateProjectQuery = `
+		INSERT INTO projects(id, name, description, metadata, design_rules, precision_model, epsg, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	postgresGetProjectQuery = `
+		SELECT id, name, description, metadata, design_rules, precision_model, epsg, created_at
+		FROM projects
+		WHERE id = $1
+	`
+
+	// Serializes updates of a project and keeps it from being deleted in the meantime
+	postgresLockProjectQuery = `
+		SELECT id
+		FROM projects
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	postgresListProjectsQuery = `
+		SELECT id, name, description, metadata, design_rules, precision_model, epsg, created_at
+		FROM projects
+		ORDER BY created_at, id
+	`
+
+	postgresUpdateDesignRulesQuery = `
+		UPDATE projects
+		SET design_rules = $2
+		WHERE id = $1
+	`
+
+	postgresUpdatePrecisionQuery = `
+		UPDATE projects
+		SET precision_model = $2
+		WHERE id = $1
+	`
+
+	postgresUpdateEPSGQuery = `
+		UPDATE projects
+		SET epsg = $2
+		WHERE id = $1
+	`
+
+	postgresDeleteProjectQuery = `
+		-- Building limits and height plateaux are deleted by cascade
+		DELETE FROM projects
+		WHERE id = $1
+	`
+
+	// Object queries are formatted with the object table name, e.g. building_limits
+
+	postgresGetObjectQuery = `
+		SELECT o.data, r.warnings, o.revision, r.author, r.created_at
+		FROM %[1]s o
+		JOIN %[1]s_revisions r ON r.project_id = o.project_id AND r.revision = o.revision
+		WHERE o.project_id = $1
+	`
+
+	postgresNextRevisionQuery = `
+		SELECT COALESCE(MAX(revision), 0) + 1
+		FROM %s_revisions
+		WHERE project_id = $1
+	`
+
+	postgresInsertRevisionQuery = `
+		INSERT INTO %s_revisions(project_id, revision, data, warnings, author, created_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+	`
+
+	postgresUpdateObjectQuery = `
+		-- Upsert
+		INSERT INTO %s(project_id, data, revision) VALUES($1, $2, $3)
+		ON CONFLICT(project_id) DO UPDATE SET data=excluded.data, revision=excluded.revision
+	`
+
+	postgresDeleteFeaturesQuery = `
+		DELETE FROM %s_features
+		WHERE project_id = $1
+	`
+
+	// Unpack the feature collection into one row per feature with a real geometry
+	postgresInsertFeaturesQuery = `
+		INSERT INTO %s_features(project_id, feature_index, feature_id, properties, geometry)
+		SELECT $1::uuid, f.ordinality - 1, f.feature->'id', f.feature->'properties',
+			ST_SetSRID(ST_GeomFromGeoJSON(f.feature->>'geometry'), 4326)
+		FROM jsonb_array_elements($2::jsonb->'features') WITH ORDINALITY AS f(feature, ordinality)
+	`
+
+	postgresListRevisionsQuery = `
+		SELECT revision, author, created_at
+		FROM %s_revisions
+		WHERE project_id = $1
+		ORDER BY revision
+	`
+
+	postgresGetRevisionQuery = `
+		SELECT data, warnings, revision, author, created_at
+		FROM %s_revisions
+		WHERE project_id = $1 AND revision = $2
+	`
+)
+
+func init() {
+	Register(DriverPostgres, func(log logr.Logger, dataSource string) Mnemosyne {
+		return NewPostgres(log, dataSource)
+	})
+}
+
+// Postgres keeps everything in PostgreSQL. Features of the current objects are also stored
+// as PostGIS geometries with spatial indexes.
+type Postgres struct {
+	log *logr.Logger
+	db  *sql.DB
+}
+
+// NewPostgres connects to the database given by a connection string, e.g. postgres://texel@localhost/texel,
+// and brings its schema up to date
+func NewPostgres(log logr.Logger, dataSource string) *Postgres {
+	mnemosyne := Postgres{
+		log: &log,
+	}
+
+	var err error
+
+	mnemosyne.db, err = sql.Open("postgres", dataSource)
+	if err != nil {
+		log.Error(err, "failed to open the database")
+		panic(err)
+	}
+
+	mnemosyne.db.SetMaxIdleConns(10)
+	mnemosyne.db.SetMaxOpenConns(10)
+
+	// Bring the schema up to date
+	version, err := mnemosyne.migrate()
+	if err != nil {
+		log.Error(err, "failed to migrate the schema")
+		panic(err)
+	}
+	log.V(2).Info("Schema is up to date", "version", version)
+
+	return &mnemosyne
+}
+
+func (m *Postgres) PingContext(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// MARK: Projects
+
+func (m *Postgres) CreateProject(name, description, metadata string) (project Project, err error) {
+	project = Project{
+		ID:          NewUUID(),
+		Name:        name,
+		Description: description,
+		Metadata:    metadata,
+		DesignRules: "{}",
+		Precision:   "{}",
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if project.Metadata == "" {
+		project.Metadata = "{}"
+	}
+
+	_, err = m.db.Exec(postgresCreateProjectQuery, project.ID, project.Name, project.Description, project.Metadata, project.DesignRules, project.Precision, project.EPSG, project.CreatedAt)
+	if err != nil {
+		m.log.Error(err, "failed to create the project")
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (m *Postgres) GetProject(projectID string) (project Project, err error) {
+	err = m.db.QueryRow(postgresGetProjectQuery, projectID).
+		Scan(&project.ID, &project.Name, &project.Description, &project.Metadata, &project.DesignRules, &project.Precision, &project.EPSG, &project.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return Project{}, ErrProjectNotFound
+	}
+
+	return
+}
+
+func (m *Postgres) ListProjects() (projects []Project, err error) {
+	rows, err := m.db.Query(postgresListProjectsQuery)
+	if err != nil {
+		m.log.Error(err, "failed to list the projects")
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects = []Project{}
+	for rows.Next() {
+		var project Project
+		if err = rows.Scan(&project.ID, &project.Name, &project.Description, &project.Metadata, &project.DesignRules, &project.Precision, &project.EPSG, &project.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+// UpdateDesignRules replaces the design rule settings of the project
+func (m *Postgres) UpdateDesignRules(projectID string, designRules string) error {
+	result, err := m.db.Exec(postgresUpdateDesignRulesQuery, projectID, designRules)
+	if err != nil {
+		m.log.Error(err, "failed to update the design rules")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// UpdatePrecision replaces the precision model of the project
+func (m *Postgres) UpdatePrecision(projectID string, precision string) error {
+	result, err := m.db.Exec(postgresUpdatePrecisionQuery, projectID, precision)
+	if err != nil {
+		m.log.Error(err, "failed to update the precision")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// UpdateEPSG replaces the metric CRS of the project
+func (m *Postgres) UpdateEPSG(projectID string, epsg int) error {
+	result, err := m.db.Exec(postgresUpdateEPSGQuery, projectID, epsg)
+	if err != nil {
+		m.log.Error(err, "failed to update the EPSG code")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// DeleteProject deletes the project along with its building limits and height plateaux
+func (m *Postgres) DeleteProject(projectID string) error {
+	result, err := m.db.Exec(postgresDeleteProjectQuery, projectID)
+	if err != nil {
+		m.log.Error(err, "failed to delete the project")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// MARK: Building Limits
+
+func (m *Postgres) GetBuildingLimits(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectBuildingLimits)
+}
+
+func (m *Postgres) UpdateBuildingLimits(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectBuildingLimits, ObjectHeightPlateaux, author, update)
+}
+
+// MARK: Height plateaux
+
+func (m *Postgres) GetHeightPlateaux(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectHeightPlateaux)
+}
+
+func (m *Postgres) UpdateHeightPlateaux(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectHeightPlateaux, ObjectBuildingLimits, author, update)
+}
+
+// MARK: Revisions
+
+// ListRevisions returns all revisions of the object, oldest first, without their data
+func (m *Postgres) ListRevisions(projectID string, object Object) (revisions []Revision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(postgresListRevisionsQuery, object), projectID)
+	if err != nil {
+		m.log.Error(err, "failed to list the revisions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = []Revision{}
+	for rows.Next() {
+		revision := Revision{Object: object}
+		if err = rows.Scan(&revision.Revision, &revision.Author, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a specific revision of the object along with its data
+func (m *Postgres) GetRevision(projectID string, object Object, revision int) (Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	r := Revision{Object: object}
+	err := m.db.QueryRowContext(ctx, fmt.Sprintf(postgresGetRevisionQuery, object), projectID, revision).
+		Scan(&r.Data, &r.Warnings, &r.Revision, &r.Author, &r.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return Revision{}, ErrRevisionNotFound
+	}
+
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
+
+// MARK: Private API
+
+func (m *Postgres) getObject(projectID string, object Object) (Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	revision := Revision{Object: object}
+	err := m.db.QueryRowContext(ctx, fmt.Sprintf(postgresGetObjectQuery, object), projectID).
+		Scan(&revision.Data, &revision.Warnings, &revision.Revision, &revision.Author, &revision.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		// The project doesn't have the object yet
+		return Revision{}, ErrNotFound
+	}
+
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return revision, nil
+}
+
+// updateObject reads the object and its complement, lets update decide on the new data and
+// appends it as the current revision, all within one transaction holding the project lock
+func (m *Postgres) updateObject(projectID string, object, complement Object, author string, update UpdateFunc) (revision Revision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		m.log.Error(err, "failed to start the transaction")
+		return Revision{}, err
+	}
+	defer tx.Rollback()
+
+	var id string
+	if err = tx.QueryRowContext(ctx, postgresLockProjectQuery, projectID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return Revision{}, ErrProjectNotFound
+		}
+
+		m.log.Error(err, "failed to lock the project")
+		return Revision{}, err
+	}
+
+	current, err := m.queryOptionalObject(ctx, tx, projectID, object)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	currentComplement, err := m.queryOptionalObject(ctx, tx, projectID, complement)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	// Nothing gets written if update refuses, e.g. on a design rule violation
+	data, warnings, err := update(current, currentComplement)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	revision = Revision{
+		Object:    object,
+		Data:      data,
+		Warnings:  warnings,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err = tx.QueryRowContext(ctx, fmt.Sprintf(postgresNextRevisionQuery, object), projectID).Scan(&revision.Revision); err != nil {
+		m.log.Error(err, "failed to allocate the revision")
+		return Revision{}, err
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{postgresInsertRevisionQuery, []any{projectID, revision.Revision, data, warnings, author, revision.CreatedAt}},
+		{postgresUpdateObjectQuery, []any{projectID, data, revision.Revision}},
+		{postgresDeleteFeaturesQuery, []any{projectID}},
+		{postgresInsertFeaturesQuery, []any{projectID, data}},
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf(statement.query, object), statement.args...); err != nil {
+			m.log.Error(err, "failed to update the object")
+			return Revision{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		m.log.Error(err, "failed to commit the transaction")
+		return Revision{}, err
+	}
+
+	return revision, nil
+}
+
+// queryOptionalObject returns nil if the project doesn't have the object yet
+func (m *Postgres) queryOptionalObject(ctx context.Context, tx *sql.Tx, projectID string, object Object) (*Revision, error) {
+	revision := Revision{Object: object}
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(postgresGetObjectQuery, object), projectID).
+		Scan(&revision.Data, &revision.Warnings, &revision.Revision, &revision.Author, &revision.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// Home-made destructor, see SQLite.Drop
+func (m *Postgres) Drop() {
+	m.db.Close()
+}
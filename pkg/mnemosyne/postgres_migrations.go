@@ -0,0 +1,209 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"fmt"
+	"time"
+)
+
+var postgresMigrations = []migration{
+	{
+		version: 1,
+		name:    "projects, building limits and height plateaux with revisions and features",
+		up: `
+			CREATE EXTENSION IF NOT EXISTS postgis;
+
+			CREATE TABLE projects (
+				id UUID PRIMARY KEY,
+				name TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				metadata JSONB NOT NULL DEFAULT '{}',
+				created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+			);
+
+			-- Every accepted version of an object
+			CREATE TABLE building_limits_revisions (
+				project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+				revision INTEGER NOT NULL,
+				data JSONB NOT NULL,
+				author TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY(project_id, revision)
+			);
+
+			CREATE TABLE height_plateaux_revisions (
+				project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+				revision INTEGER NOT NULL,
+				data JSONB NOT NULL,
+				author TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY(project_id, revision)
+			);
+
+			-- The current version of an object
+			CREATE TABLE building_limits (
+				project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+				revision INTEGER NOT NULL,
+				data JSONB NOT NULL,
+				FOREIGN KEY(project_id, revision) REFERENCES building_limits_revisions(project_id, revision)
+			);
+
+			CREATE TABLE height_plateaux (
+				project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+				revision INTEGER NOT NULL,
+				data JSONB NOT NULL,
+				FOREIGN KEY(project_id, revision) REFERENCES height_plateaux_revisions(project_id, revision)
+			);
+
+			-- Features of the current version, one row per feature
+			CREATE TABLE building_limits_features (
+				project_id UUID NOT NULL REFERENCES building_limits(project_id) ON DELETE CASCADE,
+				feature_index INTEGER NOT NULL,
+				feature_id JSONB,
+				properties JSONB,
+				geometry geometry(Geometry, 4326),
+				PRIMARY KEY(project_id, feature_index)
+			);
+
+			CREATE TABLE height_plateaux_features (
+				project_id UUID NOT NULL REFERENCES height_plateaux(project_id) ON DELETE CASCADE,
+				feature_index INTEGER NOT NULL,
+				feature_id JSONB,
+				properties JSONB,
+				geometry geometry(Geometry, 4326),
+				PRIMARY KEY(project_id, feature_index)
+			);
+
+			CREATE INDEX building_limits_features_geometry_idx ON building_limits_features USING GIST (geometry);
+			CREATE INDEX height_plateaux_features_geometry_idx ON height_plateaux_features USING GIST (geometry);
+
+			-- Magic values
+			INSERT INTO projects(id, name, description) VALUES ('feedface-cafe-beef-feed-facecafebeef', 'Playground', 'Default project used by the integration tests');
+		`,
+	},
+	{
+		version: 2,
+		name:    "design rule settings of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN design_rules JSONB NOT NULL DEFAULT '{}';
+		`,
+	},
+	{
+		version: 3,
+		name:    "design rule warnings of revisions",
+		up: `
+			ALTER TABLE building_limits_revisions ADD COLUMN warnings JSONB NOT NULL DEFAULT '[]';
+			ALTER TABLE height_plateaux_revisions ADD COLUMN warnings JSONB NOT NULL DEFAULT '[]';
+		`,
+	},
+	{
+		version: 4,
+		name:    "precision model of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN precision_model JSONB NOT NULL DEFAULT '{}';
+		`,
+	},
+	{
+		version: 5,
+		name:    "metric CRS of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN epsg INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+}
+
+const (
+	postgresCreateSchemaMigrationsQuery = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)
+	`
+
+	postgresGetSchemaVersionQuery = `
+		SELECT COALESCE(MAX(version), 0)
+		FROM schema_migrations
+	`
+
+	postgresInsertSchemaMigrationQuery = `
+		INSERT INTO schema_migrations(version, name, applied_at)
+		VALUES($1, $2, $3)
+	`
+
+	// Keeps two instances starting at once from migrating the same database
+	postgresLockSchemaMigrationsQuery = `
+		LOCK TABLE schema_migrations IN EXCLUSIVE MODE
+	`
+)
+
+// migrate applies all pending migrations in order, each one in its own transaction.
+// It refuses to touch a database whose schema is newer than the binary.
+func (m *Postgres) migrate() (version int, err error) {
+	if _, err = m.db.Exec(postgresCreateSchemaMigrationsQuery); err != nil {
+		return 0, err
+	}
+
+	if err = m.db.QueryRow(postgresGetSchemaVersionQuery).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	if latest := schemaVersion(postgresMigrations); version > latest {
+		return version, fmt.Errorf("%w: database is at version %d, binary supports up to %d", ErrSchemaTooNew, version, latest)
+	}
+
+	for _, migration := range postgresMigrations {
+		if migration.version <= version {
+			continue
+		}
+
+		applied, err := m.applyMigration(migration)
+		if err != nil {
+			return version, fmt.Errorf("migration %d (%s): %w", migration.version, migration.name, err)
+		}
+
+		if applied {
+			m.log.V(2).Info("migration applied", "version", migration.version, "name", migration.name)
+		}
+		version = migration.version
+	}
+
+	return version, nil
+}
+
+// applyMigration applies the migration unless another instance has done so in the meantime
+func (m *Postgres) applyMigration(migration migration) (applied bool, err error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(postgresLockSchemaMigrationsQuery); err != nil {
+		return false, err
+	}
+
+	var version int
+	if err = tx.QueryRow(postgresGetSchemaVersionQuery).Scan(&version); err != nil {
+		return false, err
+	}
+
+	if version >= migration.version {
+		return false, nil
+	}
+
+	if _, err = tx.Exec(migration.up); err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(postgresInsertSchemaMigrationQuery, migration.version, migration.name, time.Now().UTC()); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
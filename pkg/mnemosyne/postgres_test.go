@@ -0,0 +1,341 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Runs against the database given by TEXEL_DATABASE_URL, see `task test-integration-postgres`
+
+const (
+	postgresTestLimitsV1 = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": "a", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10, 60], [10.001, 60], [10.001, 60.001], [10, 60.001], [10, 60]]]}}
+	]}`
+
+	postgresTestLimitsV2 = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": "a", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10, 60], [10.002, 60], [10.002, 60.001], [10, 60.001], [10, 60]]]}},
+		{"type": "Feature", "id": "b", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[10.01, 60], [10.011, 60], [10.011, 60.001], [10.01, 60.001], [10.01, 60]]]}}
+	]}`
+
+	postgresTestPlateaux = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": "p", "properties": {"elevation": 3.5}, "geometry": {"type": "Polygon", "coordinates": [[[10, 60], [10.001, 60], [10.001, 60.001], [10, 60.001], [10, 60]]]}}
+	]}`
+
+	postgresTestWarnings = `[{"reason": "DesignRuleViolationSliver"}]`
+)
+
+func TestPostgresMigrate(t *testing.T) {
+	m := newTestPostgres(t)
+	latest := schemaVersion(postgresMigrations)
+
+	// NewPostgres has migrated already, running the migrations again is a no-op
+	version, err := m.migrate()
+	if err != nil || version != latest {
+		t.Fatalf("migrate again: got version %d and %v, want version %d", version, err, latest)
+	}
+
+	var applied int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied != len(postgresMigrations) {
+		t.Errorf("schema_migrations: got %d rows, want %d", applied, len(postgresMigrations))
+	}
+
+	// A schema from the future
+	if _, err := m.db.Exec(postgresInsertSchemaMigrationQuery, latest+1, "from the future", time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := m.db.Exec(`DELETE FROM schema_migrations WHERE version = $1`, latest+1); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if _, err := m.migrate(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("migrate a newer schema: got %v, want %v", err, ErrSchemaTooNew)
+	}
+}
+
+func TestPostgresProject(t *testing.T) {
+	m := newTestPostgres(t)
+
+	project, err := m.CreateProject("Test", "Created by TestPostgresProject", `{"site": "test"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = m.DeleteProject(project.ID) })
+
+	if err := m.UpdateDesignRules(project.ID, `{"DesignRuleViolationSliver": {"severity": "error"}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdatePrecision(project.ID, `{"grid": 1e-9}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdateEPSG(project.ID, 32632); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetProject(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != "Test" || got.Description != "Created by TestPostgresProject" || got.EPSG != 32632 {
+		t.Errorf("get project: got %+v", got)
+	}
+
+	assertJSONEqual(t, "metadata", got.Metadata, `{"site": "test"}`)
+	assertJSONEqual(t, "design rules", got.DesignRules, `{"DesignRuleViolationSliver": {"severity": "error"}}`)
+	assertJSONEqual(t, "precision", got.Precision, `{"grid": 1e-9}`)
+
+	projects, err := m.ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, p := range projects {
+		found = found || p.ID == project.ID
+	}
+
+	if !found {
+		t.Errorf("list projects: %s is missing", project.ID)
+	}
+
+	if _, err := m.GetProject(NewUUID()); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("get a missing project: got %v, want %v", err, ErrProjectNotFound)
+	}
+
+	if err := m.UpdateEPSG(NewUUID(), 32632); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("update a missing project: got %v, want %v", err, ErrProjectNotFound)
+	}
+}
+
+func TestPostgresRevisions(t *testing.T) {
+	m := newTestPostgres(t)
+
+	project, err := m.CreateProject("Test", "Created by TestPostgresRevisions", "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = m.DeleteProject(project.ID) })
+
+	if _, err := m.GetBuildingLimits(project.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get missing building limits: got %v, want %v", err, ErrNotFound)
+	}
+
+	// Revision 1, nothing stored yet
+	revision := mustUpdate(t, m.UpdateBuildingLimits, project.ID, "vilde", func(current, complement *Revision) (string, string, error) {
+		if current != nil || complement != nil {
+			t.Errorf("first update: got current %v and complement %v, want neither", current, complement)
+		}
+
+		return postgresTestLimitsV1, "[]", nil
+	})
+
+	if revision.Revision != 1 || revision.Author != "vilde" {
+		t.Errorf("first update: got revision %d by %s, want 1 by vilde", revision.Revision, revision.Author)
+	}
+
+	// Revision 2 sees revision 1
+	mustUpdate(t, m.UpdateBuildingLimits, project.ID, "daan", func(current, _ *Revision) (string, string, error) {
+		if current == nil || current.Revision != 1 {
+			t.Errorf("second update: got current %v, want revision 1", current)
+		}
+
+		return postgresTestLimitsV2, postgresTestWarnings, nil
+	})
+
+	// A refused update writes nothing
+	refused := errors.New("refused")
+	if _, err := m.UpdateBuildingLimits(project.ID, "daan", func(_, _ *Revision) (string, string, error) { return "", "", refused }); !errors.Is(err, refused) {
+		t.Errorf("refused update: got %v, want %v", err, refused)
+	}
+
+	current, err := m.GetBuildingLimits(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.Revision != 2 || current.Author != "daan" {
+		t.Errorf("get building limits: got revision %d by %s, want 2 by daan", current.Revision, current.Author)
+	}
+
+	assertJSONEqual(t, "building limits", current.Data, postgresTestLimitsV2)
+	assertJSONEqual(t, "warnings", current.Warnings, postgresTestWarnings)
+	assertFeatures(t, m, project.ID, ObjectBuildingLimits, 2)
+
+	revisions, err := m.ListRevisions(project.ID, ObjectBuildingLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 {
+		t.Fatalf("list revisions: got %+v, want revisions 1 and 2", revisions)
+	}
+
+	for _, r := range revisions {
+		if r.Data != "" || r.Warnings != "" {
+			t.Errorf("list revisions: revision %d comes with its data or warnings", r.Revision)
+		}
+	}
+
+	first, err := m.GetRevision(project.ID, ObjectBuildingLimits, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJSONEqual(t, "revision 1", first.Data, postgresTestLimitsV1)
+
+	if _, err := m.GetRevision(project.ID, ObjectBuildingLimits, 3); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("get a missing revision: got %v, want %v", err, ErrRevisionNotFound)
+	}
+
+	// Restoring revision 1 makes for revision 3
+	restored := mustUpdate(t, m.UpdateBuildingLimits, project.ID, "vilde", func(_, _ *Revision) (string, string, error) {
+		return first.Data, "[]", nil
+	})
+
+	if restored.Revision != 3 {
+		t.Errorf("restore: got revision %d, want 3", restored.Revision)
+	}
+
+	current, err = m.GetBuildingLimits(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJSONEqual(t, "restored building limits", current.Data, postgresTestLimitsV1)
+	assertFeatures(t, m, project.ID, ObjectBuildingLimits, 1)
+
+	// The height plateaux see the building limits as their complement
+	mustUpdate(t, m.UpdateHeightPlateaux, project.ID, "vilde", func(current, complement *Revision) (string, string, error) {
+		if current != nil || complement == nil || complement.Revision != 3 {
+			t.Errorf("height plateaux: got current %v and complement %v, want building limits revision 3 only", current, complement)
+		}
+
+		return postgresTestPlateaux, "[]", nil
+	})
+
+	assertFeatures(t, m, project.ID, ObjectHeightPlateaux, 1)
+}
+
+func TestPostgresDeleteProject(t *testing.T) {
+	m := newTestPostgres(t)
+
+	project, err := m.CreateProject("Test", "Created by TestPostgresDeleteProject", "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(data string) UpdateFunc {
+		return func(_, _ *Revision) (string, string, error) { return data, "[]", nil }
+	}
+
+	mustUpdate(t, m.UpdateBuildingLimits, project.ID, "vilde", update(postgresTestLimitsV1))
+	mustUpdate(t, m.UpdateHeightPlateaux, project.ID, "vilde", update(postgresTestPlateaux))
+
+	if err := m.DeleteProject(project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything of the project goes along with it
+	if _, err := m.GetProject(project.ID); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("get a deleted project: got %v, want %v", err, ErrProjectNotFound)
+	}
+
+	for _, object := range []Object{ObjectBuildingLimits, ObjectHeightPlateaux} {
+		if _, err := m.getObject(project.ID, object); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get %s of a deleted project: got %v, want %v", object, err, ErrNotFound)
+		}
+
+		if revisions, err := m.ListRevisions(project.ID, object); err != nil || len(revisions) != 0 {
+			t.Errorf("list %s revisions of a deleted project: got %d and %v, want none", object, len(revisions), err)
+		}
+
+		assertFeatures(t, m, project.ID, object, 0)
+	}
+
+	if err := m.DeleteProject(project.ID); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("delete a deleted project: got %v, want %v", err, ErrProjectNotFound)
+	}
+
+	if _, err := m.UpdateBuildingLimits(project.ID, "vilde", update(postgresTestLimitsV1)); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("update a deleted project: got %v, want %v", err, ErrProjectNotFound)
+	}
+}
+
+// MARK: Private API
+
+func newTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+
+	dataSource := os.Getenv("TEXEL_DATABASE_URL")
+	if dataSource == "" {
+		t.Skip("TEXEL_DATABASE_URL isn't set")
+	}
+
+	m := NewPostgres(logr.Discard(), dataSource)
+	t.Cleanup(m.Drop)
+
+	return m
+}
+
+func mustUpdate(t *testing.T, updateObject func(string, string, UpdateFunc) (Revision, error), projectID, author string, update UpdateFunc) Revision {
+	t.Helper()
+
+	revision, err := updateObject(projectID, author, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return revision
+}
+
+// assertJSONEqual compares JSON documents regardless of their formatting, JSONB doesn't keep it
+func assertJSONEqual(t *testing.T, name, got, want string) {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("%s: got %s, want %s", name, got, want)
+	}
+}
+
+// assertFeatures counts the spatially indexed features of the current object
+func assertFeatures(t *testing.T, m *Postgres, projectID string, object Object, want int) {
+	t.Helper()
+
+	var got int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM `+string(object)+`_features WHERE project_id = $1 AND ST_IsValid(geometry)`, projectID).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != want {
+		t.Errorf("%s features: got %d, want %d", object, got, want)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+type Project struct {
+	ID          string
+	Name        string
+	Description string
+	Metadata    string // JSON object
+	DesignRules string // JSON object, the design rule settings by rule name
+	Precision   string // JSON object, the snapping model of the geometries
+	EPSG        int    // metric CRS the geometries are measured in, 0 to detect it from the data
+	CreatedAt   time.Time
+}
+
+// NewUUID returns a random (version 4) UUID, the ids of projects and features alike
+// Ref: https://datatracker.ietf.org/doc/html/rfc4122#section-4.4
+func NewUUID() string {
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,37 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import "time"
+
+// Object names a versioned GeoJSON object of a project. It doubles as the table name.
+type Object string
+
+const (
+	ObjectBuildingLimits Object = "building_limits"
+	ObjectHeightPlateaux Object = "height_plateaux"
+)
+
+// Revision is an accepted version of an object. Revisions are numbered from 1 per project and object.
+type Revision struct {
+	Object    Object
+	Revision  int
+	Data      string // GeoJSON, empty when listing revisions
+	Warnings  string // JSON array of the design rule warnings the data was accepted with, empty when listing revisions
+	Author    string
+	CreatedAt time.Time
+}
+
+// UpdateFunc returns the data and warnings of the next revision given the current object and its complement,
+// either of which is nil if the project doesn't have it yet. Any error aborts the update.
+//
+// An update is retried when the object or its complement changes while it runs, so an UpdateFunc may be called
+// more than once and must not carry state from one call over to the next.
+type UpdateFunc func(current, complement *Revision) (data string, warnings string, err error)
+
+// updateAttempts bounds the retries of an update before it fails with ErrConflict
+const updateAttempts = 5
@@ -1,197 +1,598 @@
+//go:build cgo
+
 /*
  * This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/.
  */
 
-/*
- * Why Mnemosyne? -- https://en.wikipedia.org/wiki/Mnemosyne
- */
-
 package mnemosyne
 
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 
 	"github.com/go-logr/logr"
 )
 
 const (
-	databasePath = "tmp/mnemosyne.db"
-
-	scheme = `
-		CREATE TABLE projects (id UUID PRIMARY KEY);
-		CREATE TABLE building_limits (
-			project_id UUID PRIMARY KEY,
-			data JSON,
-			FOREIGN KEY(project_id) REFERENCES projects(id)
-		);
-
-		CREATE TABLE height_plateaux (
-			project_id UUID PRIMARY KEY,
-			data JSON,
-			FOREIGN KEY(project_id) REFERENCES projects(id)
-		);
-
-		-- Magic values
-		INSERT INTO projects VALUES ("feedface-cafe-beef-feed-facecafebeef");
+	createProjectQuery = `
+		INSERT INTO projects(id, name, description, metadata, design_rules, precision_model, epsg, created_at)
+		VALUES(:id, :name, :description, :metadata, :design_rules, :precision_model, :epsg, :created_at)
 	`
 
-	getBuildingLimitsQuery = `
-		SELECT data
-		FROM building_limits
-		WHERE project_id = :project_id
+	getProjectQuery = `
+		SELECT id, name, description, metadata, design_rules, precision_model, epsg, created_at
+		FROM projects
+		WHERE id = :project_id
 		LIMIT 1
 	`
 
-	updateBuildingLimitsQuery = `
-		-- Upsert
-		INSERT INTO building_limits(project_id,data) VALUES(:project_id, :data)
-  		ON CONFLICT(project_id) DO UPDATE SET data=excluded.data;
+	listProjectsQuery = `
+		SELECT id, name, description, metadata, design_rules, precision_model, epsg, created_at
+		FROM projects
+		ORDER BY created_at, id
+	`
+
+	updateDesignRulesQuery = `
+		UPDATE projects
+		SET design_rules = :design_rules
+		WHERE id = :project_id
+	`
+
+	updatePrecisionQuery = `
+		UPDATE projects
+		SET precision_model = :precision_model
+		WHERE id = :project_id
+	`
+
+	updateEPSGQuery = `
+		UPDATE projects
+		SET epsg = :epsg
+		WHERE id = :project_id
+	`
+
+	deleteProjectQuery = `
+		-- Building limits and height plateaux are deleted by cascade
+		DELETE FROM projects
+		WHERE id = :project_id
 	`
 
-	getHeightPlateauxQuery = `
-		SELECT data
-		FROM height_plateaux
+	// Object queries are formatted with the object table name, e.g. building_limits
+
+	getObjectQuery = `
+		SELECT o.data, r.warnings, o.revision, r.author, r.created_at
+		FROM %[1]s o
+		JOIN %[1]s_revisions r ON r.project_id = o.project_id AND r.revision = o.revision
+		WHERE o.project_id = :project_id
+		LIMIT 1
+	`
+
+	getObjectRevisionQuery = `
+		SELECT revision
+		FROM %s
 		WHERE project_id = :project_id
 		LIMIT 1
 	`
 
-	updateHeightPlateauxQuery = `
+	nextRevisionQuery = `
+		SELECT COALESCE(MAX(revision), 0) + 1
+		FROM %s_revisions
+		WHERE project_id = :project_id
+	`
+
+	insertRevisionQuery = `
+		INSERT INTO %s_revisions(project_id, revision, data, warnings, author, created_at)
+		VALUES(:project_id, :revision, :data, :warnings, :author, :created_at)
+	`
+
+	updateObjectQuery = `
 		-- Upsert
-		INSERT INTO height_plateaux(project_id,data) VALUES(:project_id, :data)
-  		ON CONFLICT(project_id) DO UPDATE SET data=excluded.data;
+		INSERT INTO %s(project_id, data, revision) VALUES(:project_id, :data, :revision)
+  		ON CONFLICT(project_id) DO UPDATE SET data=excluded.data, revision=excluded.revision;
+	`
+
+	listRevisionsQuery = `
+		SELECT revision, author, created_at
+		FROM %s_revisions
+		WHERE project_id = :project_id
+		ORDER BY revision
+	`
+
+	getRevisionQuery = `
+		SELECT data, warnings, revision, author, created_at
+		FROM %s_revisions
+		WHERE project_id = :project_id AND revision = :revision
+		LIMIT 1
 	`
 )
 
-type Mnemosyne struct {
+// errStaleObject tells that the object or its complement changed while an update was running
+var errStaleObject = errors.New("object changed during the update")
+
+func init() {
+	Register(DriverSQLite, func(log logr.Logger, dataSource string) Mnemosyne {
+		return NewSQLite(log, dataSource)
+	})
+}
+
+// SQLite keeps everything in a single database file
+type SQLite struct {
 	log *logr.Logger
 	db  *sql.DB
+
+	// All writes go through a single connection which takes the write lock upfront (BEGIN IMMEDIATE),
+	// so a compare-and-write transaction never races with another one
+	writer *sql.DB
 }
 
-func New(log logr.Logger) *Mnemosyne {
-	mnemosyne := Mnemosyne{
+// NewSQLite opens (or creates) the database at databasePath and brings its schema up to date
+func NewSQLite(log logr.Logger, databasePath string) *SQLite {
+	mnemosyne := SQLite{
 		log: &log,
 	}
 
 	var err error
 
-	// We don't care if the database doesn't exist
-	_ = os.Remove(databasePath)
+	if err = os.MkdirAll(filepath.Dir(databasePath), 0o755); err != nil {
+		log.Error(err, "failed to create the database directory")
+		panic(err)
+	}
+
+	// Foreign keys are off by default in SQLite
+	mnemosyne.db, err = sql.Open("sqlite3", "file:"+databasePath+"?_foreign_keys=on&_busy_timeout=5000")
 
-	mnemosyne.db, err = sql.Open("sqlite3", databasePath)
+	if err != nil {
+		log.Error(err, "failed to open the database")
+		panic(err)
+	}
 
 	mnemosyne.db.SetMaxIdleConns(50)
 	mnemosyne.db.SetMaxOpenConns(50)
 	mnemosyne.db.SetMaxOpenConns(10)
 
+	mnemosyne.writer, err = sql.Open("sqlite3", "file:"+databasePath+"?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate")
+
 	if err != nil {
-		log.Error(err, "failed to open the database")
+		log.Error(err, "failed to open the database for writing")
 		panic(err)
 	}
 
-	// Load the schema
-	if _, err = mnemosyne.db.Exec(scheme); err != nil {
-		log.Error(err, "failed to load the scheme")
+	mnemosyne.writer.SetMaxOpenConns(1)
+
+	// Bring the schema up to date
+	version, err := mnemosyne.migrate()
+	if err != nil {
+		log.Error(err, "failed to migrate the schema", "path", databasePath)
 		panic(err)
 	}
-	log.V(2).Info("Scheme is loaded successfully")
+	log.V(2).Info("Schema is up to date", "path", databasePath, "version", version)
 
 	return &mnemosyne
 }
 
-func (m *Mnemosyne) PingContext(ctx context.Context) error {
+func (m *SQLite) PingContext(ctx context.Context) error {
 	return m.db.PingContext(ctx)
 }
 
+// MARK: Projects
+
+func (m *SQLite) CreateProject(name, description, metadata string) (project Project, err error) {
+	project = Project{
+		ID:          NewUUID(),
+		Name:        name,
+		Description: description,
+		Metadata:    metadata,
+		DesignRules: "{}",
+		Precision:   "{}",
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if project.Metadata == "" {
+		project.Metadata = "{}"
+	}
+
+	_, err = m.writer.Exec(createProjectQuery,
+		sql.Named("id", project.ID),
+		sql.Named("name", project.Name),
+		sql.Named("description", project.Description),
+		sql.Named("metadata", project.Metadata),
+		sql.Named("design_rules", project.DesignRules),
+		sql.Named("precision_model", project.Precision),
+		sql.Named("epsg", project.EPSG),
+		sql.Named("created_at", project.CreatedAt),
+	)
+	if err != nil {
+		m.log.Error(err, "failed to create the project")
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (m *SQLite) GetProject(projectID string) (project Project, err error) {
+	err = m.db.QueryRow(getProjectQuery, sql.Named("project_id", projectID)).
+		Scan(&project.ID, &project.Name, &project.Description, &project.Metadata, &project.DesignRules, &project.Precision, &project.EPSG, &project.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return Project{}, ErrProjectNotFound
+	}
+
+	return
+}
+
+func (m *SQLite) ListProjects() (projects []Project, err error) {
+	rows, err := m.db.Query(listProjectsQuery)
+	if err != nil {
+		m.log.Error(err, "failed to list the projects")
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects = []Project{}
+	for rows.Next() {
+		var project Project
+		if err = rows.Scan(&project.ID, &project.Name, &project.Description, &project.Metadata, &project.DesignRules, &project.Precision, &project.EPSG, &project.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+// UpdateDesignRules replaces the design rule settings of the project
+func (m *SQLite) UpdateDesignRules(projectID string, designRules string) error {
+	result, err := m.writer.Exec(updateDesignRulesQuery, sql.Named("project_id", projectID), sql.Named("design_rules", designRules))
+	if err != nil {
+		m.log.Error(err, "failed to update the design rules")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// UpdatePrecision replaces the precision model of the project
+func (m *SQLite) UpdatePrecision(projectID string, precision string) error {
+	result, err := m.writer.Exec(updatePrecisionQuery, sql.Named("project_id", projectID), sql.Named("precision_model", precision))
+	if err != nil {
+		m.log.Error(err, "failed to update the precision")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// UpdateEPSG replaces the metric CRS of the project
+func (m *SQLite) UpdateEPSG(projectID string, epsg int) error {
+	result, err := m.writer.Exec(updateEPSGQuery, sql.Named("project_id", projectID), sql.Named("epsg", epsg))
+	if err != nil {
+		m.log.Error(err, "failed to update the EPSG code")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// DeleteProject deletes the project along with its building limits and height plateaux
+func (m *SQLite) DeleteProject(projectID string) error {
+	result, err := m.writer.Exec(deleteProjectQuery, sql.Named("project_id", projectID))
+	if err != nil {
+		m.log.Error(err, "failed to delete the project")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
 // MARK: Building Limits
 
-func (m *Mnemosyne) GetBuildingLimits(projectID string) (string, error) {
-	return m.getObject(projectID, getBuildingLimitsQuery)
+func (m *SQLite) GetBuildingLimits(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectBuildingLimits)
 }
 
-func (m *Mnemosyne) UpdateBuildingLimits(projectID string, data string) (err error) {
-	return m.updateObject(projectID, updateBuildingLimitsQuery, data)
+// UpdateBuildingLimits stores the data returned by update as a new revision of the building limits.
+// The complement handed to update is the current height plateaux.
+func (m *SQLite) UpdateBuildingLimits(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectBuildingLimits, ObjectHeightPlateaux, author, update)
 }
 
 // MARK: Height plateaux
 
-func (m *Mnemosyne) GetHeightPlateaux(projectID string) (string, error) {
-	return m.getObject(projectID, getHeightPlateauxQuery)
+func (m *SQLite) GetHeightPlateaux(projectID string) (Revision, error) {
+	return m.getObject(projectID, ObjectHeightPlateaux)
 }
 
-func (m *Mnemosyne) UpdateHeightPlateaux(projectID string, data string) (err error) {
-	return m.updateObject(projectID, updateHeightPlateauxQuery, data)
+// UpdateHeightPlateaux stores the data returned by update as a new revision of the height plateaux.
+// The complement handed to update is the current building limits.
+func (m *SQLite) UpdateHeightPlateaux(projectID string, author string, update UpdateFunc) (Revision, error) {
+	return m.updateObject(projectID, ObjectHeightPlateaux, ObjectBuildingLimits, author, update)
 }
 
-// MARK: Private API
+// MARK: Revisions
 
-func (m *Mnemosyne) getObject(projectID string, sqlQuery string) (objectData string, err error) {
+// ListRevisions returns all revisions of the object, oldest first, without their data
+func (m *SQLite) ListRevisions(projectID string, object Object) (revisions []Revision, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	tx, err := m.db.BeginTx(ctx, nil)
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(listRevisionsQuery, object), sql.Named("project_id", projectID))
 	if err != nil {
-		m.log.Error(err, "failed to start the transaction")
-		return "", err
+		m.log.Error(err, "failed to list the revisions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = []Revision{}
+	for rows.Next() {
+		revision := Revision{Object: object}
+		if err = rows.Scan(&revision.Revision, &revision.Author, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a specific revision of the object along with its data
+func (m *SQLite) GetRevision(projectID string, object Object, revision int) (Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	r := Revision{Object: object}
+	err := m.db.QueryRowContext(ctx, fmt.Sprintf(getRevisionQuery, object), sql.Named("project_id", projectID), sql.Named("revision", revision)).
+		Scan(&r.Data, &r.Warnings, &r.Revision, &r.Author, &r.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return Revision{}, ErrRevisionNotFound
 	}
 
-	stmt, err := tx.PrepareContext(ctx, sqlQuery)
 	if err != nil {
-		m.log.Error(err, "failed to prepare the SQL statement")
+		return Revision{}, err
 	}
 
-	if err = stmt.QueryRowContext(ctx, sql.Named("project_id", projectID)).Scan(&objectData); err != nil {
-		if err == sql.ErrNoRows {
-			// The project doesn't have any building limits yet
-			return "", ErrNotFound
-		}
+	return r, nil
+}
+
+// MARK: Private API
+
+func (m *SQLite) getObject(projectID string, object Object) (revision Revision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		m.log.Error(err, "failed to start the transaction")
+		return Revision{}, err
+	}
+	defer tx.Rollback()
 
-		return "", err
+	if revision, err = queryObject(ctx, tx, projectID, object); err != nil {
+		return Revision{}, err
 	}
 
 	if err = tx.Commit(); err != nil {
 		m.log.Error(err, "failed to commit the transaction")
-		return "", err
+		return Revision{}, err
 	}
 
 	return
 }
 
-func (m *Mnemosyne) updateObject(projectID string, sqlQuery string, data string) (err error) {
-	tx, err := m.db.Begin()
+// updateObject lets update decide on the new data outside of any transaction, the design rules may take a while,
+// and appends it as the current revision within one write transaction. The outcome is only written if neither the
+// object nor its complement changed in the meantime, otherwise update runs again, up to updateAttempts times.
+func (m *SQLite) updateObject(projectID string, object, complement Object, author string, update UpdateFunc) (Revision, error) {
+	for attempt := 0; attempt < updateAttempts; attempt++ {
+		revision, err := m.tryUpdateObject(projectID, object, complement, author, update)
+		if err != errStaleObject {
+			return revision, err
+		}
+
+		m.log.V(3).Info("object changed during the update, retrying", "object", object, "attempt", attempt+1)
+	}
+
+	return Revision{}, ErrConflict
+}
+
+// tryUpdateObject runs a single attempt of updateObject, errStaleObject tells that it lost the race
+func (m *SQLite) tryUpdateObject(projectID string, object, complement Object, author string, update UpdateFunc) (revision Revision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	current, currentComplement, err := m.readObjects(ctx, projectID, object, complement)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	// Nothing gets written if update refuses, e.g. on a design rule violation
+	data, warnings, err := update(current, currentComplement)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	tx, err := m.writer.BeginTx(ctx, nil)
 	if err != nil {
 		m.log.Error(err, "failed to start the transaction")
-		return err
+		return Revision{}, err
 	}
+	defer tx.Rollback()
+
+	// The write lock is held from here on, compare what update saw with what is stored now
+	for _, read := range []struct {
+		object   Object
+		revision *Revision
+	}{{object, current}, {complement, currentComplement}} {
+		stored, err := queryObjectRevision(ctx, tx, projectID, read.object)
+		if err != nil {
+			return Revision{}, err
+		}
 
-	stmt, err := tx.Prepare(sqlQuery)
+		if stored != revisionNumber(read.revision) {
+			return Revision{}, errStaleObject
+		}
+	}
+
+	revision = Revision{
+		Object:    object,
+		Data:      data,
+		Warnings:  warnings,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err = tx.QueryRowContext(ctx, fmt.Sprintf(nextRevisionQuery, object), sql.Named("project_id", projectID)).Scan(&revision.Revision); err != nil {
+		m.log.Error(err, "failed to allocate the revision")
+		return Revision{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(insertRevisionQuery, object),
+		sql.Named("project_id", projectID),
+		sql.Named("revision", revision.Revision),
+		sql.Named("data", data),
+		sql.Named("warnings", warnings),
+		sql.Named("author", author),
+		sql.Named("created_at", revision.CreatedAt),
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return Revision{}, ErrProjectNotFound
+		}
+
+		m.log.Error(err, "failed to insert the revision")
+		return Revision{}, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(updateObjectQuery, object))
 	if err != nil {
 		m.log.Error(err, "failed to prepare the SQL statement")
-		return err
+		return Revision{}, err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(sql.Named("project_id", projectID), sql.Named("data", data)); err != nil {
-		m.log.Error(err, "failed to update the building limits")
-		return err
+	if _, err = stmt.ExecContext(ctx, sql.Named("project_id", projectID), sql.Named("data", data), sql.Named("revision", revision.Revision)); err != nil {
+		if isForeignKeyViolation(err) {
+			return Revision{}, ErrProjectNotFound
+		}
+
+		m.log.Error(err, "failed to update the object")
+		return Revision{}, err
 	}
 
 	if err = tx.Commit(); err != nil {
 		m.log.Error(err, "failed to commit the transaction")
-		return err
+		return Revision{}, err
 	}
 
-	return nil
+	return revision, nil
+}
+
+// readObjects reads the object and its complement, either of which is nil if the project doesn't have it yet
+func (m *SQLite) readObjects(ctx context.Context, projectID string, object, complement Object) (current, currentComplement *Revision, err error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		m.log.Error(err, "failed to start the transaction")
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if current, err = queryOptionalObject(ctx, tx, projectID, object); err != nil {
+		return nil, nil, err
+	}
+
+	if currentComplement, err = queryOptionalObject(ctx, tx, projectID, complement); err != nil {
+		return nil, nil, err
+	}
+
+	return current, currentComplement, tx.Commit()
+}
+
+func queryObject(ctx context.Context, tx *sql.Tx, projectID string, object Object) (revision Revision, err error) {
+	revision.Object = object
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(getObjectQuery, object), sql.Named("project_id", projectID)).
+		Scan(&revision.Data, &revision.Warnings, &revision.Revision, &revision.Author, &revision.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// The project doesn't have the object yet
+			return Revision{}, ErrNotFound
+		}
+
+		return Revision{}, err
+	}
+
+	return
+}
+
+// queryOptionalObject is queryObject returning nil instead of ErrNotFound
+func queryOptionalObject(ctx context.Context, tx *sql.Tx, projectID string, object Object) (*Revision, error) {
+	revision, err := queryObject(ctx, tx, projectID, object)
+
+	if err == ErrNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// queryObjectRevision returns the current revision of the object, 0 if the project doesn't have it yet
+func queryObjectRevision(ctx context.Context, tx *sql.Tx, projectID string, object Object) (revision int, err error) {
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(getObjectRevisionQuery, object), sql.Named("project_id", projectID)).Scan(&revision)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return revision, err
+}
+
+// revisionNumber is the number of a revision, 0 for a missing one
+func revisionNumber(revision *Revision) int {
+	if revision == nil {
+		return 0
+	}
+
+	return revision.Revision
+}
+
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
 }
 
 // Home-made destructor. Inspired by Rust.
 // Ref: https://rust-unofficial.github.io/patterns/idioms/dtor-finally.html
-func (m *Mnemosyne) Drop() {
+func (m *SQLite) Drop() {
 	m.db.Close()
+	m.writer.Close()
 }
@@ -0,0 +1,184 @@
+//go:build cgo
+
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+var sqliteMigrations = []migration{
+	{
+		version: 1,
+		name:    "projects, building limits and height plateaux",
+		up: `
+			CREATE TABLE projects (
+				id UUID PRIMARY KEY,
+				name TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				metadata JSON NOT NULL DEFAULT '{}',
+				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE building_limits (
+				project_id UUID PRIMARY KEY,
+				data JSON,
+				FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE
+			);
+
+			CREATE TABLE height_plateaux (
+				project_id UUID PRIMARY KEY,
+				data JSON,
+				FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE
+			);
+
+			-- Magic values
+			INSERT INTO projects(id, name, description) VALUES ("feedface-cafe-beef-feed-facecafebeef", "Playground", "Default project used by the integration tests");
+		`,
+	},
+	{
+		version: 2,
+		name:    "revisions of building limits and height plateaux",
+		up: `
+			CREATE TABLE building_limits_revisions (
+				project_id UUID NOT NULL,
+				revision INTEGER NOT NULL,
+				data JSON NOT NULL,
+				author TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				PRIMARY KEY(project_id, revision),
+				FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE
+			);
+
+			CREATE TABLE height_plateaux_revisions (
+				project_id UUID NOT NULL,
+				revision INTEGER NOT NULL,
+				data JSON NOT NULL,
+				author TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				PRIMARY KEY(project_id, revision),
+				FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE
+			);
+
+			ALTER TABLE building_limits ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+			ALTER TABLE height_plateaux ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+			-- The existing objects become the first revision
+			INSERT INTO building_limits_revisions(project_id, revision, data, author, created_at)
+			SELECT project_id, 1, data, 'unknown', CURRENT_TIMESTAMP FROM building_limits;
+
+			INSERT INTO height_plateaux_revisions(project_id, revision, data, author, created_at)
+			SELECT project_id, 1, data, 'unknown', CURRENT_TIMESTAMP FROM height_plateaux;
+		`,
+	},
+	{
+		version: 3,
+		name:    "design rule settings of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN design_rules JSON NOT NULL DEFAULT '{}';
+		`,
+	},
+	{
+		version: 4,
+		name:    "design rule warnings of revisions",
+		up: `
+			ALTER TABLE building_limits_revisions ADD COLUMN warnings JSON NOT NULL DEFAULT '[]';
+			ALTER TABLE height_plateaux_revisions ADD COLUMN warnings JSON NOT NULL DEFAULT '[]';
+		`,
+	},
+	{
+		version: 5,
+		name:    "precision model of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN precision_model JSON NOT NULL DEFAULT '{}';
+		`,
+	},
+	{
+		version: 6,
+		name:    "metric CRS of projects",
+		up: `
+			ALTER TABLE projects ADD COLUMN epsg INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+}
+
+const (
+	createSchemaMigrationsQuery = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`
+
+	getSchemaVersionQuery = `
+		SELECT COALESCE(MAX(version), 0)
+		FROM schema_migrations
+	`
+
+	insertSchemaMigrationQuery = `
+		INSERT INTO schema_migrations(version, name, applied_at)
+		VALUES(:version, :name, :applied_at)
+	`
+)
+
+// migrate applies all pending migrations in order, each one in its own transaction.
+// It refuses to touch a database whose schema is newer than the binary.
+func (m *SQLite) migrate() (version int, err error) {
+	if _, err = m.writer.Exec(createSchemaMigrationsQuery); err != nil {
+		return 0, err
+	}
+
+	if err = m.writer.QueryRow(getSchemaVersionQuery).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	if latest := schemaVersion(sqliteMigrations); version > latest {
+		return version, fmt.Errorf("%w: database is at version %d, binary supports up to %d", ErrSchemaTooNew, version, latest)
+	}
+
+	for _, migration := range sqliteMigrations {
+		if migration.version <= version {
+			continue
+		}
+
+		if err = m.applyMigration(migration); err != nil {
+			return version, fmt.Errorf("migration %d (%s): %w", migration.version, migration.name, err)
+		}
+
+		m.log.V(2).Info("migration applied", "version", migration.version, "name", migration.name)
+		version = migration.version
+	}
+
+	return version, nil
+}
+
+func (m *SQLite) applyMigration(migration migration) error {
+	tx, err := m.writer.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(migration.up); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(insertSchemaMigrationQuery,
+		sql.Named("version", migration.version),
+		sql.Named("name", migration.name),
+		sql.Named("applied_at", time.Now().UTC()),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
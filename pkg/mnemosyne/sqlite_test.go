@@ -0,0 +1,94 @@
+//go:build cgo
+
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/.
+ */
+
+package mnemosyne
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func newTestSQLite(t *testing.T) *SQLite {
+	t.Helper()
+
+	m := NewSQLite(logr.Discard(), filepath.Join(t.TempDir(), "mnemosyne.db"))
+	t.Cleanup(m.Drop)
+
+	return m
+}
+
+// The design rules run outside of the write transaction, other writers aren't blocked meanwhile
+func TestSQLiteUpdateConcurrentWriter(t *testing.T) {
+	m := newTestSQLite(t)
+
+	project, err := m.CreateProject("Concurrency", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := 0
+	revision, err := m.UpdateBuildingLimits(project.ID, "slow", func(current, _ *Revision) (string, string, error) {
+		runs++
+
+		// Only the first run loses the race
+		if runs == 1 {
+			if _, err := m.UpdateBuildingLimits(project.ID, "fast", func(_, _ *Revision) (string, string, error) {
+				return "{}", "[]", nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if runs == 2 && revisionNumber(current) != 1 {
+			t.Errorf("second run: got revision %d, want 1", revisionNumber(current))
+		}
+
+		return "{}", "[]", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runs != 2 || revision.Revision != 2 {
+		t.Errorf("got %d runs and revision %d, want 2 runs and revision 2", runs, revision.Revision)
+	}
+}
+
+func TestSQLiteUpdateConflict(t *testing.T) {
+	m := newTestSQLite(t)
+
+	project, err := m.CreateProject("Concurrency", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every run of the update loses the race against another writer
+	runs := 0
+	_, err = m.UpdateHeightPlateaux(project.ID, "slow", func(_, _ *Revision) (string, string, error) {
+		runs++
+
+		if _, err := m.UpdateBuildingLimits(project.ID, "fast", func(_, _ *Revision) (string, string, error) {
+			return "{}", "[]", nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		return "{}", "[]", nil
+	})
+
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v, want %v", err, ErrConflict)
+	}
+
+	if runs != updateAttempts {
+		t.Errorf("got %d runs, want %d", runs, updateAttempts)
+	}
+}